@@ -0,0 +1,66 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ControllerConfigs contains the controller configs of all seed namespaces that are managed by a single
+// controller instance.
+type ControllerConfigs struct {
+	configs    map[string]*ControllerConfig
+	namespaces []string
+}
+
+// NewControllerConfigs bundles the given controller configs, each config is responsible for one seed namespace.
+// all configs are required to use the same seed client.
+func NewControllerConfigs(configs ...*ControllerConfig) (*ControllerConfigs, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("at least one controller config must be specified")
+	}
+
+	cs := &ControllerConfigs{
+		configs: map[string]*ControllerConfig{},
+	}
+
+	for _, c := range configs {
+		if _, ok := cs.configs[c.GetSeedNamespace()]; ok {
+			return nil, fmt.Errorf("duplicate controller config for seed namespace %q", c.GetSeedNamespace())
+		}
+
+		cs.configs[c.GetSeedNamespace()] = c
+		cs.namespaces = append(cs.namespaces, c.GetSeedNamespace())
+	}
+
+	slices.Sort(cs.namespaces)
+
+	return cs, nil
+}
+
+// Get returns the controller config of the given seed namespace.
+func (c *ControllerConfigs) Get(namespace string) (*ControllerConfig, bool) {
+	cc, ok := c.configs[namespace]
+	return cc, ok
+}
+
+// GetAll returns the controller configs of all seed namespaces ordered by namespace.
+func (c *ControllerConfigs) GetAll() []*ControllerConfig {
+	var result []*ControllerConfig
+	for _, ns := range c.namespaces {
+		result = append(result, c.configs[ns])
+	}
+
+	return result
+}
+
+// GetSeedNamespaces returns all seed namespaces the controllers act on.
+func (c *ControllerConfigs) GetSeedNamespaces() []string {
+	return slices.Clone(c.namespaces)
+}
+
+// GetSeedClient returns the seed client, which is shared by all controller configs.
+func (c *ControllerConfigs) GetSeedClient() client.Client {
+	return c.configs[c.namespaces[0]].GetSeedClient()
+}
@@ -59,6 +59,21 @@ type NewControllerConfig struct {
 	FirewallHealthTimeout time.Duration
 	// CreateTimeout is used in the firewall creation phase to recreate a firewall when it does not become ready.
 	CreateTimeout time.Duration
+	// OrphanCheckInterval is the interval in which the metal-api is checked for firewalls of the cluster that
+	// have no firewall resource anymore. zero disables the check.
+	OrphanCheckInterval time.Duration
+	// OrphanGracePeriod is the duration after which an orphaned firewall is freed. zero only reports orphaned firewalls.
+	OrphanGracePeriod time.Duration
+
+	// ReleaseIndexURL points to a release index of the firewall-controller and the nftables-exporter.
+	// it is used for automatically updating the versions of these components.
+	ReleaseIndexURL string
+	// ReleaseIndexConfigMap is the name of a config map in the controller namespace that contains a release index.
+	// it can be used instead of the release index url.
+	ReleaseIndexConfigMap string
+	// ReleaseIndexConfigMapNamespace is the namespace of the release index config map, which is the namespace
+	// the controller is running in.
+	ReleaseIndexConfigMapNamespace string
 
 	// SkipValidation skips configuration validation, use this only for testing purposes
 	SkipValidation bool
@@ -90,6 +105,12 @@ type ControllerConfig struct {
 	progressDeadline      time.Duration
 	firewallHealthTimeout time.Duration
 	createTimeout         time.Duration
+	orphanCheckInterval   time.Duration
+	orphanGracePeriod     time.Duration
+
+	releaseIndexURL                string
+	releaseIndexConfigMap          string
+	releaseIndexConfigMapNamespace string
 }
 
 func New(c *NewControllerConfig) (*ControllerConfig, error) {
@@ -103,24 +124,29 @@ func New(c *NewControllerConfig) (*ControllerConfig, error) {
 	}
 
 	return &ControllerConfig{
-		seedClient:            c.SeedClient,
-		seedConfig:            c.SeedConfig,
-		seedNamespace:         c.SeedNamespace,
-		seedAPIServerURL:      c.SeedAPIServerURL,
-		shootClient:           c.ShootClient,
-		shootConfig:           c.ShootConfig,
-		shootNamespace:        c.ShootNamespace,
-		shootAPIServerURL:     c.ShootAPIServerURL,
-		shootAccess:           c.ShootAccess,
-		sshKeySecretNamespace: c.SSHKeySecretNamespace,
-		sshKeySecretName:      c.SSHKeySecretName,
-		shootAccessHelper:     helper,
-		metal:                 c.Metal,
-		clusterTag:            c.ClusterTag,
-		safetyBackoff:         c.SafetyBackoff,
-		progressDeadline:      c.ProgressDeadline,
-		firewallHealthTimeout: c.FirewallHealthTimeout,
-		createTimeout:         c.CreateTimeout,
+		seedClient:                     c.SeedClient,
+		seedConfig:                     c.SeedConfig,
+		seedNamespace:                  c.SeedNamespace,
+		seedAPIServerURL:               c.SeedAPIServerURL,
+		shootClient:                    c.ShootClient,
+		shootConfig:                    c.ShootConfig,
+		shootNamespace:                 c.ShootNamespace,
+		shootAPIServerURL:              c.ShootAPIServerURL,
+		shootAccess:                    c.ShootAccess,
+		sshKeySecretNamespace:          c.SSHKeySecretNamespace,
+		sshKeySecretName:               c.SSHKeySecretName,
+		shootAccessHelper:              helper,
+		metal:                          c.Metal,
+		clusterTag:                     c.ClusterTag,
+		safetyBackoff:                  c.SafetyBackoff,
+		progressDeadline:               c.ProgressDeadline,
+		firewallHealthTimeout:          c.FirewallHealthTimeout,
+		createTimeout:                  c.CreateTimeout,
+		orphanCheckInterval:            c.OrphanCheckInterval,
+		orphanGracePeriod:              c.OrphanGracePeriod,
+		releaseIndexURL:                c.ReleaseIndexURL,
+		releaseIndexConfigMap:          c.ReleaseIndexConfigMap,
+		releaseIndexConfigMapNamespace: c.ReleaseIndexConfigMapNamespace,
 	}, nil
 
 }
@@ -188,6 +214,19 @@ func (c *NewControllerConfig) validate() error {
 	if c.CreateTimeout < 0 {
 		return fmt.Errorf("create timeout must be specified")
 	}
+	if c.OrphanCheckInterval < 0 {
+		return fmt.Errorf("orphan check interval must not be negative")
+	}
+	if c.OrphanGracePeriod < 0 {
+		return fmt.Errorf("orphan grace period must not be negative")
+	}
+
+	if c.ReleaseIndexURL != "" && c.ReleaseIndexConfigMap != "" {
+		return fmt.Errorf("only one of release index url and release index config map can be specified")
+	}
+	if c.ReleaseIndexConfigMap != "" && c.ReleaseIndexConfigMapNamespace == "" {
+		return fmt.Errorf("release index config map namespace must be specified")
+	}
 
 	return nil
 }
@@ -271,3 +310,68 @@ func (c *ControllerConfig) GetFirewallHealthTimeout() time.Duration {
 func (c *ControllerConfig) GetCreateTimeout() time.Duration {
 	return c.createTimeout
 }
+
+func (c *ControllerConfig) GetOrphanCheckInterval() time.Duration {
+	return c.orphanCheckInterval
+}
+
+func (c *ControllerConfig) GetOrphanGracePeriod() time.Duration {
+	return c.orphanGracePeriod
+}
+
+// GetProgressDeadlineFor returns the progress deadline of the given firewall deployment, which
+// falls back to the globally configured progress deadline.
+func (c *ControllerConfig) GetProgressDeadlineFor(deploy *v2.FirewallDeployment) time.Duration {
+	if deploy.Spec.ProgressDeadline != nil {
+		return deploy.Spec.ProgressDeadline.Duration
+	}
+	return c.progressDeadline
+}
+
+// GetFirewallHealthTimeoutFor returns the health timeout for the firewalls of the given firewall set, which
+// falls back to the globally configured health timeout.
+func (c *ControllerConfig) GetFirewallHealthTimeoutFor(set *v2.FirewallSet) time.Duration {
+	if set.Spec.HealthTimeout != nil {
+		return set.Spec.HealthTimeout.Duration
+	}
+	return c.firewallHealthTimeout
+}
+
+// GetCreateTimeoutFor returns the create timeout for the firewalls of the given firewall set, which
+// falls back to the globally configured create timeout.
+func (c *ControllerConfig) GetCreateTimeoutFor(set *v2.FirewallSet) time.Duration {
+	if set.Spec.CreateTimeout != nil {
+		return set.Spec.CreateTimeout.Duration
+	}
+	return c.createTimeout
+}
+
+// GetFirewallHealthTimeoutForDeployment returns the health timeout for the spare firewalls of the given firewall
+// deployment, which falls back to the globally configured health timeout.
+func (c *ControllerConfig) GetFirewallHealthTimeoutForDeployment(deploy *v2.FirewallDeployment) time.Duration {
+	if deploy.Spec.HealthTimeout != nil {
+		return deploy.Spec.HealthTimeout.Duration
+	}
+	return c.firewallHealthTimeout
+}
+
+// GetCreateTimeoutForDeployment returns the create timeout for the spare firewalls of the given firewall
+// deployment, which falls back to the globally configured create timeout.
+func (c *ControllerConfig) GetCreateTimeoutForDeployment(deploy *v2.FirewallDeployment) time.Duration {
+	if deploy.Spec.CreateTimeout != nil {
+		return deploy.Spec.CreateTimeout.Duration
+	}
+	return c.createTimeout
+}
+
+func (c *ControllerConfig) GetReleaseIndexURL() string {
+	return c.releaseIndexURL
+}
+
+func (c *ControllerConfig) GetReleaseIndexConfigMap() string {
+	return c.releaseIndexConfigMap
+}
+
+func (c *ControllerConfig) GetReleaseIndexConfigMapNamespace() string {
+	return c.releaseIndexConfigMapNamespace
+}
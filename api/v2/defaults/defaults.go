@@ -3,6 +3,7 @@ package defaults
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
@@ -16,45 +17,50 @@ import (
 
 const (
 	DefaultFirewallReconcileInterval = "10s"
+	DefaultCanarySoakDuration        = 10 * time.Minute
+	DefaultMaxSurge                  = 1
+	DefaultMaxUnavailable            = 0
+	DefaultRecreationWindow          = 1 * time.Hour
+	DefaultQuarantineTTL             = 24 * time.Hour
 )
 
 type (
 	firewallDefaulter struct {
-		c   *config.ControllerConfig
-		log logr.Logger
+		configs *config.ControllerConfigs
+		log     logr.Logger
 	}
 	firewallSetDefaulter struct {
-		c   *config.ControllerConfig
-		fd  admission.Defaulter[*v2.Firewall]
-		log logr.Logger
+		configs *config.ControllerConfigs
+		fd      admission.Defaulter[*v2.Firewall]
+		log     logr.Logger
 	}
 	firewallDeploymentDefaulter struct {
-		c   *config.ControllerConfig
-		fd  admission.Defaulter[*v2.Firewall]
-		log logr.Logger
+		configs *config.ControllerConfigs
+		fd      admission.Defaulter[*v2.Firewall]
+		log     logr.Logger
 	}
 )
 
-func NewFirewallDefaulter(log logr.Logger, c *config.ControllerConfig) (admission.Defaulter[*v2.Firewall], error) {
-	return &firewallDefaulter{log: log, c: c}, nil
+func NewFirewallDefaulter(log logr.Logger, configs *config.ControllerConfigs) (admission.Defaulter[*v2.Firewall], error) {
+	return &firewallDefaulter{log: log, configs: configs}, nil
 }
 
-func NewFirewallSetDefaulter(log logr.Logger, c *config.ControllerConfig) (admission.Defaulter[*v2.FirewallSet], error) {
-	fd, err := NewFirewallDefaulter(log, c)
+func NewFirewallSetDefaulter(log logr.Logger, configs *config.ControllerConfigs) (admission.Defaulter[*v2.FirewallSet], error) {
+	fd, err := NewFirewallDefaulter(log, configs)
 	if err != nil {
 		return nil, err
 	}
 
-	return &firewallSetDefaulter{log: log, c: c, fd: fd}, nil
+	return &firewallSetDefaulter{log: log, configs: configs, fd: fd}, nil
 }
 
-func NewFirewallDeploymentDefaulter(log logr.Logger, c *config.ControllerConfig) (admission.Defaulter[*v2.FirewallDeployment], error) {
-	fd, err := NewFirewallDefaulter(log, c)
+func NewFirewallDeploymentDefaulter(log logr.Logger, configs *config.ControllerConfigs) (admission.Defaulter[*v2.FirewallDeployment], error) {
+	fd, err := NewFirewallDefaulter(log, configs)
 	if err != nil {
 		return nil, err
 	}
 
-	return &firewallDeploymentDefaulter{log: log, c: c, fd: fd}, nil
+	return &firewallDeploymentDefaulter{log: log, configs: configs, fd: fd}, nil
 }
 
 func (r *firewallDefaulter) Default(ctx context.Context, f *v2.Firewall) error {
@@ -68,6 +74,12 @@ func (r *firewallDefaulter) Default(ctx context.Context, f *v2.Firewall) error {
 func (r *firewallSetDefaulter) Default(ctx context.Context, f *v2.FirewallSet) error {
 	r.log.Info("defaulting firewallset resource", "name", f.GetName(), "namespace", f.GetNamespace())
 
+	if f.Spec.RecreationBudget != nil && f.Spec.RecreationBudget.Window == nil {
+		f.Spec.RecreationBudget.Window = &metav1.Duration{Duration: DefaultRecreationWindow}
+	}
+	if f.Spec.Quarantine != nil && f.Spec.Quarantine.TTL == nil {
+		f.Spec.Quarantine.TTL = &metav1.Duration{Duration: DefaultQuarantineTTL}
+	}
 	if f.Spec.Selector == nil {
 		f.Spec.Selector = f.Spec.Template.Labels
 	}
@@ -83,19 +95,46 @@ func (r *firewallDeploymentDefaulter) Default(ctx context.Context, f *v2.Firewal
 	if f.Spec.Strategy == "" {
 		f.Spec.Strategy = v2.StrategyRollingUpdate
 	}
+	if f.Spec.Strategy == v2.StrategyCanary {
+		if f.Spec.Canary == nil {
+			f.Spec.Canary = &v2.FirewallCanary{}
+		}
+		if f.Spec.Canary.SoakDuration == nil {
+			f.Spec.Canary.SoakDuration = &metav1.Duration{Duration: DefaultCanarySoakDuration}
+		}
+	}
+	if f.Spec.RollingUpdate != nil {
+		if f.Spec.RollingUpdate.MaxSurge == nil {
+			f.Spec.RollingUpdate.MaxSurge = new(DefaultMaxSurge)
+		}
+		if f.Spec.RollingUpdate.MaxUnavailable == nil {
+			f.Spec.RollingUpdate.MaxUnavailable = new(DefaultMaxUnavailable)
+		}
+	}
+	if f.Spec.RecreationBudget != nil && f.Spec.RecreationBudget.Window == nil {
+		f.Spec.RecreationBudget.Window = &metav1.Duration{Duration: DefaultRecreationWindow}
+	}
+	if f.Spec.Quarantine != nil && f.Spec.Quarantine.TTL == nil {
+		f.Spec.Quarantine.TTL = &metav1.Duration{Duration: DefaultQuarantineTTL}
+	}
 	if f.Spec.Selector == nil {
 		f.Spec.Selector = f.Spec.Template.Labels
 	}
 
 	defaultFirewallSpec(&f.Spec.Template.Spec)
 
+	c, ok := r.configs.Get(f.Namespace)
+	if !ok {
+		return fmt.Errorf("namespace %q is not managed by this controller", f.Namespace)
+	}
+
 	if f.Spec.Template.Spec.Userdata == "" {
-		shootConfig, err := r.c.GetShootAccessHelper().RESTConfig(ctx)
+		shootConfig, err := c.GetShootAccessHelper().RESTConfig(ctx)
 		if err != nil {
 			return err
 		}
 
-		err = helper.EnsureFirewallControllerRBAC(ctx, r.c.GetSeedConfig(), shootConfig, f, r.c.GetShootNamespace(), r.c.GetShootAccess())
+		err = helper.EnsureFirewallControllerRBAC(ctx, c.GetSeedConfig(), shootConfig, f, c.GetShootNamespace(), c.GetShootAccess())
 		if err != nil {
 			return err
 		}
@@ -103,8 +142,8 @@ func (r *firewallDeploymentDefaulter) Default(ctx context.Context, f *v2.Firewal
 		shootKubeconfig, err := helper.GetAccessKubeconfig(&helper.AccessConfig{
 			Ctx:          ctx,
 			Config:       shootConfig,
-			Namespace:    r.c.GetShootNamespace(),
-			ApiServerURL: r.c.GetShootAPIServerURL(),
+			Namespace:    c.GetShootNamespace(),
+			ApiServerURL: c.GetShootAPIServerURL(),
 			Deployment:   f,
 			ForShoot:     true,
 		})
@@ -114,9 +153,9 @@ func (r *firewallDeploymentDefaulter) Default(ctx context.Context, f *v2.Firewal
 
 		seedKubeconfig, err := helper.GetAccessKubeconfig(&helper.AccessConfig{
 			Ctx:          ctx,
-			Config:       r.c.GetSeedConfig(),
-			Namespace:    r.c.GetSeedNamespace(),
-			ApiServerURL: r.c.GetSeedAPIServerURL(),
+			Config:       c.GetSeedConfig(),
+			Namespace:    c.GetSeedNamespace(),
+			ApiServerURL: c.GetSeedAPIServerURL(),
 			Deployment:   f,
 		})
 		if err != nil {
@@ -132,7 +171,7 @@ func (r *firewallDeploymentDefaulter) Default(ctx context.Context, f *v2.Firewal
 	}
 
 	if len(f.Spec.Template.Spec.SSHPublicKeys) == 0 {
-		key, err := getSSHPublicKey(ctx, r.c.GetSeedClient(), r.c.GetSSHKeySecretName(), r.c.GetSSHKeySecretNamespace())
+		key, err := getSSHPublicKey(ctx, c.GetSeedClient(), c.GetSSHKeySecretName(), c.GetSSHKeySecretNamespace())
 		if err != nil {
 			return err
 		}
@@ -22,6 +22,25 @@ const (
 	RollSetAnnotation = "firewall.metal-stack.io/roll-set"
 	// RevisionAnnotation stores the revision number of a resource.
 	RevisionAnnotation = "firewall.metal-stack.io/revision"
+	// RollbackToAnnotation can be used to roll back a firewall deployment to the template of a given revision.
+	// The value of the annotation needs to be the revision number of a firewall set kept in the revision history.
+	// The controller will cleanup the annotation automatically and create a new firewall set from the template of this revision.
+	RollbackToAnnotation = "firewall.metal-stack.io/rollback-to"
+	// PreRolloutHooksAnnotation stores the names of the pre-rollout hooks that have succeeded for a firewall set.
+	PreRolloutHooksAnnotation = "firewall.metal-stack.io/pre-rollout-hooks"
+	// PostRolloutHooksAnnotation stores the names of the post-rollout hooks that have succeeded for a firewall set.
+	// The annotation is added with an empty value when a firewall set replaces old firewall sets, post-rollout hooks are only
+	// run for firewall sets carrying this annotation.
+	PostRolloutHooksAnnotation = "firewall.metal-stack.io/post-rollout-hooks"
+	// RecreateFallbackAnnotation is added to a firewall set that was created by the recreate strategy because a firewall deployment
+	// with the auto strategy did not have enough free machines for a rolling update. As long as firewalls of older firewall sets
+	// are present, the deployment continues with the recreate strategy.
+	RecreateFallbackAnnotation = "firewall.metal-stack.io/recreate-fallback"
+	// FirewallImportAnnotation can be used to import existing firewalls of the metal-api into a firewall set, e.g. firewalls
+	// that were created by other tooling. The value contains the comma-separated machine ids of the firewalls.
+	// When added to a firewall deployment, the annotation is passed to its first firewall set. The controller will cleanup
+	// the annotation automatically after the firewalls were imported.
+	FirewallImportAnnotation = "firewall.metal-stack.io/import"
 
 	// FirewallNoControllerConnectionAnnotation can be used as an annotation to the firewall resource in order
 	// to indicate that the firewall-controller does not connect to the firewall monitor. this way, the replica
@@ -45,6 +64,28 @@ const (
 
 	// FirewallControllerSetAnnotation is a tag added to the firewall entity indicating to which set a firewall belongs to.
 	FirewallControllerSetAnnotation = "firewall.metal.stack.io/set"
+	// FirewallEgressNetworkAnnotation is a tag added to static egress ips allocated by the firewall-controller-manager indicating
+	// for which network of the egress rules the ip was allocated.
+	FirewallEgressNetworkAnnotation = "firewall.metal-stack.io/egress-network"
+	// AllocatedEgressIPsAnnotation is added to a firewall set and contains the comma-separated static egress ips that were allocated
+	// by the firewall-controller-manager and added to the egress rules of the template. It is used for removing these ips again when
+	// the template of a firewall deployment is restored from a firewall set.
+	AllocatedEgressIPsAnnotation = "firewall.metal-stack.io/allocated-egress-ips"
+	// FirewallImportedMachineIDAnnotation is added to an imported firewall and contains the machine id of the firewall in the
+	// metal-api. It is used for finding the firewall as long as the machine status of the firewall is not populated.
+	FirewallImportedMachineIDAnnotation = "firewall.metal-stack.io/imported-machine-id"
+
+	// FirewallQuarantinedLabel is added to a firewall that was quarantined instead of being deleted, the value contains the name
+	// of the firewall deployment. Quarantined firewalls are not adopted by firewall sets and are deleted along with the deployment.
+	FirewallQuarantinedLabel = "firewall.metal-stack.io/quarantined"
+	// FirewallQuarantinedUntilAnnotation stores the point in time in RFC3339 format until a quarantined firewall is kept.
+	// After this point in time, the firewall gets deleted. The value can be changed to extend the quarantine.
+	// An invalid value is treated as an expired quarantine.
+	FirewallQuarantinedUntilAnnotation = "firewall.metal-stack.io/quarantined-until"
+
+	// FirewallSpareLabel is added to a spare firewall of a firewall deployment, the value contains the name of the deployment.
+	// Spare firewalls are only adopted by firewall sets of this deployment on scale up.
+	FirewallSpareLabel = "firewall.metal-stack.io/spare"
 )
 
 // IsAnnotationPresent returns true if the given object has an annotation with a given
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/metal-stack/metal-lib/pkg/pointer"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 const (
@@ -26,6 +28,7 @@ const (
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
 // +kubebuilder:printcolumn:name="Machine ID",type="string",JSONPath=".status.machineStatus.machineID"
 // +kubebuilder:printcolumn:name="Last Event",type="string",JSONPath=".status.machineStatus.lastEvent.event"
+// +kubebuilder:printcolumn:name="Rack",type="string",priority=1,JSONPath=".status.machineStatus.rack"
 // +kubebuilder:printcolumn:name="Distance",type="string",priority=1,JSONPath=".distance"
 // +kubebuilder:printcolumn:name="Version",type="string",JSONPath=".status.controllerStatus.actualVersion"
 // +kubebuilder:printcolumn:name="Spec Version",type="string",priority=1,JSONPath=".spec.controllerVersion"
@@ -49,11 +52,18 @@ type FirewallSpec struct {
 	// Size is the machine size of the firewall.
 	// An update on this field requires the recreation of the physical firewall and can therefore lead to traffic interruption for the cluster.
 	Size string `json:"size"`
+	// FallbackSizes is an ordered list of machine sizes that are used for the creation of the firewall when no machine
+	// of the given size is available. The size that was used for the creation is stored in the machine status.
+	FallbackSizes []string `json:"fallbackSizes,omitempty"`
 	// Image is the os image of the firewall.
 	// An update on this field requires the recreation of the physical firewall and can therefore lead to traffic interruption for the cluster.
 	Image string `json:"image"`
 	// Partition is the partition in which the firewall resides.
 	Partition string `json:"partition"`
+	// FallbackPartitions is an ordered list of partitions that are used for the creation of the firewall when no machine
+	// is available in the given partition. This is only possible when the networks of the firewall are available in these partitions.
+	// The partition that was used for the creation is stored in the machine status.
+	FallbackPartitions []string `json:"fallbackPartitions,omitempty"`
 	// Project is the project in which the firewall resides.
 	Project string `json:"project"`
 	// Networks are the networks to which this firewall is connected.
@@ -130,6 +140,10 @@ type EgressRuleSNAT struct {
 	NetworkID string `json:"networkID"`
 	// IPs contains the ips used as source addresses for packets leaving the specified network.
 	IPs []string `json:"ips"`
+	// Allocate is the amount of static ips that are allocated by the firewall-controller-manager in the specified network and used
+	// in addition to the given ips. The allocated ips are tagged with the cluster and released when the firewall deployment is deleted.
+	// Only considered in the template of a firewall deployment.
+	Allocate int `json:"allocate,omitempty"`
 }
 
 // RateLimit contains the rate limit rule for a network.
@@ -188,6 +202,8 @@ const (
 	// FirewallProvisioned indicates that all health conditions have been met at least once.
 	// Once set to true, it stays true and is used to detect condition degradation.
 	FirewallProvisioned ConditionType = "Provisioned"
+	// FirewallDrifted indicates that the machine of the firewall in the metal-api does not match the firewall spec anymore.
+	FirewallDrifted ConditionType = "Drifted"
 )
 
 // ShootAccess contains secret references to construct a shoot client in the firewall-controller to update its firewall monitor.
@@ -220,6 +236,12 @@ type MachineStatus struct {
 	LastEvent *MachineLastEvent `json:"lastEvent,omitempty"`
 	// ImageID contains the used os image id of the firewall (the fully qualified version, no shorthand version).
 	ImageID string `json:"imageID,omitempty"`
+	// Size is the machine size of the firewall, which may be a fallback size.
+	Size string `json:"size,omitempty"`
+	// Partition is the partition of the firewall, which may be a fallback partition.
+	Partition string `json:"partition,omitempty"`
+	// Rack is the id of the rack the firewall machine is placed in.
+	Rack string `json:"rack,omitempty"`
 }
 
 // MachineLastEvent contains the last provisioning event of the machine.
@@ -522,3 +544,23 @@ func EvaluateFirewallStatus(fw *Firewall, createTimeout, healthTimeout time.Dura
 		}
 	}
 }
+
+// FirewallRollReasons returns the changes between the current and the desired firewall spec that cannot be applied
+// to existing firewalls, such that new firewalls need to be rolled out.
+func FirewallRollReasons(current, desired *FirewallSpec) []string {
+	var reasons []string
+
+	if desired.Size != current.Size {
+		reasons = append(reasons, fmt.Sprintf("size changed from %q to %q", current.Size, desired.Size))
+	}
+
+	if desired.Image != current.Image {
+		reasons = append(reasons, fmt.Sprintf("image changed from %q to %q", current.Image, desired.Image))
+	}
+
+	if !sets.New(current.Networks...).Equal(sets.New(desired.Networks...)) {
+		reasons = append(reasons, fmt.Sprintf("networks changed from %q to %q", strings.Join(current.Networks, ","), strings.Join(desired.Networks, ",")))
+	}
+
+	return reasons
+}
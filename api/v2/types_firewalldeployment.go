@@ -1,6 +1,9 @@
 package v2
 
 import (
+	"fmt"
+	"strings"
+
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -36,16 +39,88 @@ const (
 	StrategyRollingUpdate FirewallUpdateStrategy = "RollingUpdate"
 	// StrategyRecreate removes the old firewall set and then creates a new one
 	StrategyRecreate FirewallUpdateStrategy = "Recreate"
+	// StrategyCanary first creates a new firewall set with a single replica, waits until it is ready for a soak period and then scales it up and removes the old one
+	StrategyCanary FirewallUpdateStrategy = "Canary"
+	// StrategyAuto behaves like the rolling update strategy but falls back to the recreate strategy when there are not enough free machines for a new firewall set
+	StrategyAuto FirewallUpdateStrategy = "Auto"
 )
 
+// MachineImageConstraintPatchOnly only allows machine image auto updates within the major and minor version of the current image.
+const MachineImageConstraintPatchOnly = "patch-only"
+
 // FirewallDeploymentSpec specifies the firewall deployment.
 type FirewallDeploymentSpec struct {
 	// Strategy describes the strategy how firewalls are updated in case the update requires a physical recreation of the firewalls.
 	// Defaults to RollingUpdate strategy.
 	Strategy FirewallUpdateStrategy `json:"strategy,omitempty"`
+	// Canary contains configuration for the canary update strategy.
+	// Only considered when using the Canary strategy.
+	Canary *FirewallCanary `json:"canary,omitempty"`
+	// RollingUpdate contains configuration for replacing firewalls gradually during a rolling update.
+	// If not set, the new firewall set is created with all replicas at once.
+	// When replacing firewalls gradually, the progress deadline is measured from the last time a firewall of the new firewall set was created or became ready.
+	// Only considered when using the RollingUpdate or Auto strategy.
+	RollingUpdate *FirewallRollingUpdate `json:"rollingUpdate,omitempty"`
+	// StepwiseTrafficShift lowers the distance of a new firewall set step by step instead of swapping it to the shortest distance at once.
+	// Between the steps, the controller waits until the firewall-controllers have configured the distance. Before old firewall sets are
+	// removed, the controller verifies that the new firewalls receive traffic through the device statistics of the firewall monitors.
+	// Only supported by the RollingUpdate and Auto strategies.
+	StepwiseTrafficShift bool `json:"stepwiseTrafficShift,omitempty"`
+	// Hooks are run during a rolling update and block the update until they have succeeded.
+	// Only supported by the RollingUpdate and Auto strategies.
+	Hooks *FirewallRolloutHooks `json:"hooks,omitempty"`
 	// Replicas is the amount of firewall replicas targeted to be running.
 	// Defaults to 1.
 	Replicas int `json:"replicas,omitempty"`
+	// Paused indicates that the deployment is paused. While paused, no firewall sets are created or updated,
+	// which allows staging multiple template changes that are rolled out at once when the deployment is resumed.
+	// Status information is still updated.
+	Paused bool `json:"paused,omitempty"`
+	// RevisionHistoryLimit is the number of old firewall sets to retain as revision history in order to allow rollbacks.
+	// Old firewall sets in the revision history are scaled down to zero replicas.
+	// Defaults to 0, which means that old firewall sets are deleted.
+	RevisionHistoryLimit *int `json:"revisionHistoryLimit,omitempty"`
+	// AutoRollback deletes the new firewall set and returns to the previous firewall set in case the progress deadline
+	// of an update was exceeded.
+	// Only considered when using the RollingUpdate or Canary strategy.
+	AutoRollback bool `json:"autoRollback,omitempty"`
+	// ProgressDeadline is the maximum time for a firewall set to become ready before the update is considered as failed.
+	// Must be greater than zero if set.
+	// Overrides the progress deadline configured for the controller.
+	ProgressDeadline *metav1.Duration `json:"progressDeadline,omitempty"`
+	// CreateTimeout is the maximum time a firewall may take to get ready after creation before it gets deleted.
+	// This is passed down to the firewall sets and overrides the create timeout configured for the controller.
+	CreateTimeout *metav1.Duration `json:"createTimeout,omitempty"`
+	// HealthTimeout is the maximum time a firewall may be unhealthy before it gets deleted.
+	// This is passed down to the firewall sets and overrides the health timeout configured for the controller.
+	HealthTimeout *metav1.Duration `json:"healthTimeout,omitempty"`
+	// MinAvailable is the amount of ready firewalls that are kept when firewalls are deleted because of a health timeout.
+	// The last ready firewall of a set is never deleted because of a health timeout, regardless of this setting.
+	// This is passed down to the firewall sets.
+	MinAvailable *int `json:"minAvailable,omitempty"`
+	// RecreationBudget limits how often firewalls are recreated because of a timeout.
+	// If not set, firewalls are recreated without limitation.
+	// This is passed down to the firewall sets.
+	RecreationBudget *FirewallRecreationBudget `json:"recreationBudget,omitempty"`
+	// Quarantine enables the quarantine of unhealthy firewalls. Instead of being deleted because of a timeout or on scale down,
+	// an unhealthy firewall is released from its firewall set, gets the longest distance and is labeled as quarantined.
+	// The machine of a quarantined firewall stays allocated for root-cause analysis until the TTL has expired.
+	// If not set, unhealthy firewalls are deleted.
+	// This is passed down to the firewall sets.
+	Quarantine *FirewallQuarantine `json:"quarantine,omitempty"`
+	// DriftRemediation defines how a drift between a firewall and its machine in the metal-api is remediated, e.g. when a metal
+	// admin has changed the machine by hand. A drift is always reported in the Drifted condition of the firewall. With RollSet,
+	// a drift triggers a roll of the firewall set.
+	// Defaults to None.
+	// This is passed down to the firewall sets.
+	DriftRemediation FirewallDriftRemediation `json:"driftRemediation,omitempty"`
+	// Spares is the amount of firewalls that are kept allocated with the template's spec without attracting any traffic.
+	// When a firewall set scales up, it adopts a spare firewall instead of allocating a new machine, which makes replacing a firewall a lot faster.
+	// Defaults to 0.
+	Spares int `json:"spares,omitempty"`
+	// StatusSinks configure where the egress cidrs of the firewalls are published, e.g. for allowing traffic of the firewalls in external systems.
+	// The Gardener infrastructure of the cluster is updated regardless of this setting when running in a shoot namespace of a Gardener seed.
+	StatusSinks []FirewallStatusSink `json:"statusSinks,omitempty"`
 	// AutoUpdate defines the behavior for automatic updates.
 	AutoUpdate FirewallAutoUpdate `json:"autoUpdate"`
 	// Selector is a label query over firewalls that should match the replicas count.
@@ -57,10 +132,131 @@ type FirewallDeploymentSpec struct {
 	Template FirewallTemplateSpec `json:"template"`
 }
 
+// FirewallCanary contains configuration for the canary update strategy.
+type FirewallCanary struct {
+	// SoakDuration is the time the canary firewall set needs to be ready before it gets scaled up to the full amount of replicas
+	// and the old firewall sets are removed.
+	// Defaults to 10m.
+	SoakDuration *metav1.Duration `json:"soakDuration,omitempty"`
+}
+
+// FirewallRollingUpdate contains configuration for replacing firewalls gradually during a rolling update.
+type FirewallRollingUpdate struct {
+	// MaxSurge is the maximum number of firewalls that can be allocated above the desired amount of replicas during the update.
+	// Must be greater than zero when using stepwise traffic shift or pre-rollout hooks, which run before the first ready firewall of
+	// the old firewall sets is removed.
+	// Defaults to 1.
+	MaxSurge *int `json:"maxSurge,omitempty"`
+	// MaxUnavailable is the maximum number of firewalls that can be unavailable during the update.
+	// Defaults to 0.
+	MaxUnavailable *int `json:"maxUnavailable,omitempty"`
+}
+
+// FirewallRolloutHooks contains hooks that are run during a rolling update.
+type FirewallRolloutHooks struct {
+	// PreRollout hooks are run when the new firewall set has become ready but before traffic is moved to the new firewall set,
+	// i.e. before the new firewall set gets the shortest distance and before the old firewall sets are removed.
+	PreRollout []FirewallRolloutHook `json:"preRollout,omitempty"`
+	// PostRollout hooks are run after the traffic was moved to the new firewall set, i.e. when the new firewall set has the
+	// shortest distance and the old firewall sets were removed. They are only run for firewall sets that have replaced old
+	// firewall sets while the hooks were configured. Failing post-rollout hooks are reported in the status but do not block
+	// the deployment.
+	PostRollout []FirewallRolloutHook `json:"postRollout,omitempty"`
+}
+
+// FirewallRolloutHook is a hook that is run during a rolling update. Exactly one of HTTP or Job needs to be specified.
+type FirewallRolloutHook struct {
+	// Name is the name of the hook, which needs to be unique within the hooks of a phase.
+	Name string `json:"name"`
+	// HTTP is a hook that sends a POST request to an HTTP endpoint. The hook succeeds when the endpoint responds with a 2xx status code.
+	HTTP *FirewallRolloutHTTPHook `json:"http,omitempty"`
+	// Job is a hook that runs a Kubernetes job in the seed. The hook succeeds when the job has completed successfully.
+	Job *FirewallRolloutJobHook `json:"job,omitempty"`
+}
+
+// FirewallRolloutHTTPHook sends a POST request to an HTTP endpoint.
+type FirewallRolloutHTTPHook struct {
+	// URL is the URL of the endpoint. The request body contains information on the firewall deployment and the firewall set as JSON.
+	URL string `json:"url"`
+}
+
+// FirewallRolloutJobHook runs a Kubernetes job in the seed.
+type FirewallRolloutJobHook struct {
+	// CronJobName is the name of a CronJob in the namespace of the firewall deployment, which job template is used for creating the job.
+	// It is recommended to suspend this CronJob such that it only serves as a template.
+	CronJobName string `json:"cronJobName"`
+}
+
+// FirewallStatusSink publishes the egress cidrs of the firewalls. Exactly one of ConfigMap, Deployment or Resource needs to be specified.
+type FirewallStatusSink struct {
+	// ConfigMap publishes the egress cidrs in a config map in the namespace of the firewall deployment.
+	ConfigMap *FirewallConfigMapStatusSink `json:"configMap,omitempty"`
+	// Deployment publishes the egress cidrs in the status of the firewall deployment.
+	Deployment *FirewallDeploymentStatusSink `json:"deployment,omitempty"`
+	// Resource publishes the egress cidrs in a field of an arbitrary resource in the namespace of the firewall deployment.
+	Resource *FirewallResourceStatusSink `json:"resource,omitempty"`
+}
+
+// FirewallConfigMapStatusSink publishes the egress cidrs in a config map.
+type FirewallConfigMapStatusSink struct {
+	// Name is the name of the config map, which is created if it does not exist.
+	Name string `json:"name"`
+	// Key is the key of the config map data that contains the egress cidrs as a comma-separated list.
+	// Defaults to egressCIDRs.
+	Key string `json:"key,omitempty"`
+}
+
+// FirewallDeploymentStatusSink publishes the egress cidrs in the status of the firewall deployment.
+type FirewallDeploymentStatusSink struct{}
+
+// FirewallResourceStatusSink publishes the egress cidrs in a field of an arbitrary resource.
+type FirewallResourceStatusSink struct {
+	// APIVersion is the api version of the resource, e.g. example.com/v1.
+	APIVersion string `json:"apiVersion"`
+	// Kind is the kind of the resource.
+	Kind string `json:"kind"`
+	// Name is the name of the resource. The resource is not created if it does not exist.
+	Name string `json:"name"`
+	// JSONPath is the path of the field that contains the egress cidrs as a list of strings, e.g. .status.egressCIDRs.
+	// Only simple field paths are supported. Fields below .status are patched through the status subresource.
+	JSONPath string `json:"jsonPath"`
+}
+
 type FirewallAutoUpdate struct {
 	// MachineImage auto updates the os image of the firewall within the maintenance time window
 	// in case a newer version of the os is available.
 	MachineImage bool `json:"machineImage"`
+	// MachineImageConstraint restricts the os image versions that the firewall is auto updated to.
+	// It can either be a semantic version constraint like "~3.0" or ">=3.0 <4.0", in which case the
+	// newest image satisfying the constraint is chosen, or "patch-only", which only allows updates
+	// within the major and minor version of the current image.
+	// If not set, the image is updated to the latest image of the major and minor version of the current image.
+	MachineImageConstraint string `json:"machineImageConstraint,omitempty"`
+	// ControllerVersion auto updates the firewall-controller version within the maintenance time window
+	// to the newest release with the same major version from the release index.
+	ControllerVersion bool `json:"controllerVersion,omitempty"`
+	// NftablesExporterVersion auto updates the nftables-exporter version within the maintenance time window
+	// to the newest release with the same major version from the release index.
+	NftablesExporterVersion bool `json:"nftablesExporterVersion,omitempty"`
+	// MaintenanceWindows are time windows in which automatic updates are performed.
+	// If no maintenance windows are defined, automatic updates are only performed when the firewall deployment
+	// was annotated with the maintenance annotation.
+	MaintenanceWindows []FirewallMaintenanceWindow `json:"maintenanceWindows,omitempty"`
+}
+
+// FirewallMaintenanceWindow defines a recurring time window in which automatic updates are performed.
+type FirewallMaintenanceWindow struct {
+	// Weekdays are the days of the week on which the maintenance window begins, e.g. Monday.
+	// If empty, the maintenance window begins on every day.
+	Weekdays []string `json:"weekdays,omitempty"`
+	// Begin is the time of day when the maintenance window begins in the format HH:MM.
+	Begin string `json:"begin"`
+	// End is the time of day when the maintenance window ends in the format HH:MM.
+	// If the end is before the begin, the maintenance window ends on the following day.
+	End string `json:"end"`
+	// Timezone is the IANA name of the time zone of begin and end, e.g. Europe/Berlin.
+	// Defaults to UTC.
+	Timezone string `json:"timezone,omitempty"`
 }
 
 // FirewallDeploymentStatus contains current status information on the firewall deployment.
@@ -73,12 +269,51 @@ type FirewallDeploymentStatus struct {
 	ReadyReplicas int `json:"readyReplicas"`
 	// ProgressingReplicas is the amount of firewall replicas that are currently unhealthy in the latest managed firewall set.
 	UnhealthyReplicas int `json:"unhealthyReplicas"`
+	// SpareReplicas is the amount of spare firewalls that are currently allocated for this deployment.
+	SpareReplicas int `json:"spareReplicas"`
+	// ReadySpareReplicas is the amount of spare firewalls that are ready to be adopted by a firewall set.
+	ReadySpareReplicas int `json:"readySpareReplicas"`
+	// EgressIPs contains the static egress ips that were allocated for the egress rules of the template.
+	EgressIPs []EgressRuleSNAT `json:"egressIPs,omitempty"`
+	// ReleasingEgressIPs contains the allocated static egress ips that are not requested anymore. They are released as soon as
+	// the firewalls do not use them anymore.
+	ReleasingEgressIPs []string `json:"releasingEgressIPs,omitempty"`
+	// EgressCIDRs contains the egress cidrs of the firewalls, only published when configured in the status sinks of the deployment.
+	EgressCIDRs []string `json:"egressCIDRs,omitempty"`
 	// ObservedRevision is a counter that increases with each firewall set roll that was made.
 	ObservedRevision int `json:"observedRevision"`
+	// RolloutPlan describes how the firewall sets are changed for reaching the current spec of the deployment.
+	// Unless the deployment is paused, the plan is computed in the same reconciliation that starts the rollout,
+	// so it only serves as a preview of staged changes while the deployment is paused.
+	RolloutPlan *FirewallRolloutPlan `json:"rolloutPlan,omitempty"`
 	// Conditions contain the latest available observations of a firewall deployment's current state.
 	Conditions Conditions `json:"conditions"`
 }
 
+// FirewallRolloutPlan describes how the firewall sets of a deployment are changed for reaching the current spec.
+// For an unpaused deployment, it reports the rollout that is already in progress.
+type FirewallRolloutPlan struct {
+	// NewSetRequired is true if the current spec requires a new firewall set, which replaces all firewalls of the deployment.
+	NewSetRequired bool `json:"newSetRequired"`
+	// Reasons contains the changes that require a new firewall set.
+	Reasons []string `json:"reasons,omitempty"`
+	// NewSetDistance is the distance of the new firewall set until the rollout has finished.
+	NewSetDistance *FirewallDistance `json:"newSetDistance,omitempty"`
+	// SetsToDelete contains the names of the firewall sets whose firewalls are deleted when the rollout has finished.
+	// The firewall sets are kept in the revision history if configured.
+	SetsToDelete []string `json:"setsToDelete,omitempty"`
+	// Distances contains the expected distances of the existing firewall sets during the rollout.
+	Distances []FirewallSetDistance `json:"distances,omitempty"`
+}
+
+// FirewallSetDistance contains the distance of a firewall set.
+type FirewallSetDistance struct {
+	// Name is the name of the firewall set.
+	Name string `json:"name"`
+	// Distance is the distance of the firewall set.
+	Distance FirewallDistance `json:"distance"`
+}
+
 const (
 	// FirewallDeploymentAvailable indicates whether the deployment has reached the desired amount of replicas or not.
 	FirewallDeploymentAvailable ConditionType = "Available"
@@ -86,6 +321,14 @@ const (
 	FirewallDeploymentProgressing ConditionType = "Progressing"
 	// FirewallDeploymentRBACProvisioned indicates whether the rbac permissions for the firewall-controller to communicate with the api server were provisioned.
 	FirewallDeploymentRBACProvisioned ConditionType = "RBACProvisioned"
+	// FirewallDeploymentRolledBack indicates whether the deployment was automatically rolled back to the previous firewall set.
+	FirewallDeploymentRolledBack ConditionType = "RolledBack"
+	// FirewallDeploymentPreRolloutHooks indicates whether the pre-rollout hooks of the latest firewall set have succeeded.
+	FirewallDeploymentPreRolloutHooks ConditionType = "PreRolloutHooks"
+	// FirewallDeploymentPostRolloutHooks indicates whether the post-rollout hooks of the latest firewall set have succeeded.
+	FirewallDeploymentPostRolloutHooks ConditionType = "PostRolloutHooks"
+	// FirewallDeploymentCapacityAvailable indicates whether there were enough free machines for the firewalls of the latest firewall set.
+	FirewallDeploymentCapacityAvailable ConditionType = "CapacityAvailable"
 )
 
 // FirewallDeploymentList contains a list of firewalls deployments
@@ -106,3 +349,25 @@ func (f *FirewallDeploymentList) GetItems() []*FirewallDeployment {
 	}
 	return result
 }
+
+// Fields returns the fields of the json path of the resource status sink, e.g. [status egressCIDRs] for .status.egressCIDRs.
+func (s *FirewallResourceStatusSink) Fields() ([]string, error) {
+	path := strings.TrimSuffix(strings.TrimPrefix(s.JSONPath, "{"), "}")
+
+	if !strings.HasPrefix(path, ".") {
+		return nil, fmt.Errorf("json path must start with a dot")
+	}
+
+	fields := strings.Split(strings.TrimPrefix(path, "."), ".")
+	for _, f := range fields {
+		if f == "" || strings.ContainsAny(f, "[]*@$?()") {
+			return nil, fmt.Errorf("json path must only contain simple fields")
+		}
+	}
+
+	if fields[0] == "metadata" {
+		return nil, fmt.Errorf("json path must not point into the metadata")
+	}
+
+	return fields, nil
+}
@@ -27,6 +27,10 @@ func FirewallManagedByTag() string {
 	return fmt.Sprintf("%s=%s", FirewallControllerManagedByAnnotation, FirewallControllerManager)
 }
 
+func FirewallEgressIPTag(networkID string) string {
+	return fmt.Sprintf("%s=%s", FirewallEgressNetworkAnnotation, networkID)
+}
+
 func (f FirewallDistance) Pointer() *FirewallDistance {
 	return &f
 }
@@ -67,6 +71,60 @@ type FirewallSetSpec struct {
 	// Distance defines the as-path length of the firewalls.
 	// This field is typically orchestrated by the deployment controller.
 	Distance FirewallDistance `json:"distance"`
+	// CreateTimeout is the maximum time a firewall may take to get ready after creation before it gets deleted.
+	// Overrides the create timeout configured for the controller.
+	// This field is typically orchestrated by the deployment controller.
+	CreateTimeout *metav1.Duration `json:"createTimeout,omitempty"`
+	// HealthTimeout is the maximum time a firewall may be unhealthy before it gets deleted.
+	// Overrides the health timeout configured for the controller.
+	// This field is typically orchestrated by the deployment controller.
+	HealthTimeout *metav1.Duration `json:"healthTimeout,omitempty"`
+	// MinAvailable is the amount of ready firewalls that are kept when firewalls are deleted because of a health timeout.
+	// The last ready firewall of a set is never deleted because of a health timeout, regardless of this setting.
+	// This field is typically orchestrated by the deployment controller.
+	MinAvailable *int `json:"minAvailable,omitempty"`
+	// RecreationBudget limits how often firewalls of this set are recreated because of a timeout.
+	// If not set, firewalls are recreated without limitation.
+	// This field is typically orchestrated by the deployment controller.
+	RecreationBudget *FirewallRecreationBudget `json:"recreationBudget,omitempty"`
+	// Quarantine enables the quarantine of unhealthy firewalls. Instead of being deleted because of a timeout or on scale down,
+	// an unhealthy firewall is released from this set, gets the longest distance and is labeled as quarantined.
+	// The machine of a quarantined firewall stays allocated for root-cause analysis until the TTL has expired.
+	// If not set, unhealthy firewalls are deleted.
+	// This field is typically orchestrated by the deployment controller.
+	Quarantine *FirewallQuarantine `json:"quarantine,omitempty"`
+	// DriftRemediation defines how a drift between a firewall of this set and its machine in the metal-api is remediated.
+	// Defaults to None.
+	// This field is typically orchestrated by the deployment controller.
+	DriftRemediation FirewallDriftRemediation `json:"driftRemediation,omitempty"`
+}
+
+// FirewallDriftRemediation defines how a drift between a firewall and its machine in the metal-api is remediated.
+type FirewallDriftRemediation string
+
+const (
+	// DriftRemediationNone only reports a drift in the Drifted condition of the firewall.
+	DriftRemediationNone FirewallDriftRemediation = "None"
+	// DriftRemediationRollSet triggers a roll of the firewall set of a drifted firewall through the roll set annotation.
+	DriftRemediationRollSet FirewallDriftRemediation = "RollSet"
+)
+
+// FirewallQuarantine configures the quarantine of unhealthy firewalls.
+type FirewallQuarantine struct {
+	// TTL is the time a quarantined firewall is kept before it gets deleted.
+	// Defaults to 24h.
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
+// FirewallRecreationBudget limits how often firewalls of a set are recreated because of a timeout.
+// When the budget is exhausted, timed out firewalls are not recreated until the oldest recreation
+// has left the window, such that a broken firewall template does not result in endless machine allocations.
+type FirewallRecreationBudget struct {
+	// MaxRecreations is the maximum amount of firewall recreations within the window.
+	MaxRecreations int `json:"maxRecreations"`
+	// Window is the time window in which the recreations are counted.
+	// Defaults to 1h.
+	Window *metav1.Duration `json:"window,omitempty"`
 }
 
 // FirewallDistance defines the as-path length of firewalls, influencing how strong they attract
@@ -93,8 +151,28 @@ type FirewallSetStatus struct {
 	UnhealthyReplicas int `json:"unhealthyReplicas"`
 	// ObservedRevision is a counter that increases with each firewall set roll that was made.
 	ObservedRevision int `json:"observedRevision"`
+	// Recreations contains the recent firewall recreations that were caused by a timeout.
+	// Recreations that are older than the window of the recreation budget are removed.
+	Recreations []FirewallRecreation `json:"recreations,omitempty"`
+	// Conditions contain the latest available observations of a firewall set's current state.
+	Conditions Conditions `json:"conditions,omitempty"`
 }
 
+// FirewallRecreation describes the recreation of a firewall because of a timeout.
+type FirewallRecreation struct {
+	// FirewallName is the name of the firewall that was deleted.
+	FirewallName string `json:"firewallName"`
+	// Reason is the reason why the firewall was deleted.
+	Reason string `json:"reason"`
+	// Timestamp is the point in time when the firewall was deleted.
+	Timestamp metav1.Time `json:"timestamp"`
+}
+
+const (
+	// FirewallSetReplicaFailure indicates whether timed out firewalls of the set are not recreated anymore because the recreation budget is exhausted.
+	FirewallSetReplicaFailure ConditionType = "ReplicaFailure"
+)
+
 // FirewallSetList contains a list of firewalls sets
 //
 // +kubebuilder:object:root=true
@@ -12,6 +12,7 @@ import (
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -83,6 +84,9 @@ func validateFirewallSpec(f *v2.FirewallSpec, fldPath *field.Path) field.ErrorLi
 
 	allErrs = append(allErrs, r.check()...)
 
+	allErrs = append(allErrs, validateFallbacks(f.Size, f.FallbackSizes, fldPath.Child("fallbackSizes"))...)
+	allErrs = append(allErrs, validateFallbacks(f.Partition, f.FallbackPartitions, fldPath.Child("fallbackPartitions"))...)
+
 	d, err := time.ParseDuration(f.Interval)
 	if err != nil {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("interval"), f.Interval, "interval must be parsable as a duration"))
@@ -115,6 +119,10 @@ func validateFirewallSpec(f *v2.FirewallSpec, fldPath *field.Path) field.ErrorLi
 				allErrs = append(allErrs, field.Invalid(fldPath.Child("egressRules").Child("ips"), ip, fmt.Sprintf("error parsing ip: %v", err)))
 			}
 		}
+
+		if rule.Allocate < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("egressRules").Child("allocate"), rule.Allocate, "allocate cannot be a negative number"))
+		}
 	}
 
 	for _, prefix := range f.InternalPrefixes {
@@ -147,6 +155,27 @@ func validateFirewallSpec(f *v2.FirewallSpec, fldPath *field.Path) field.ErrorLi
 	return allErrs
 }
 
+func validateFallbacks(primary string, fallbacks []string, fldPath *field.Path) field.ErrorList {
+	var (
+		allErrs field.ErrorList
+		seen    = sets.New(primary)
+	)
+
+	for i, fallback := range fallbacks {
+		if fallback == "" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i), fallback, "fallback must not be empty"))
+			continue
+		}
+		if seen.Has(fallback) {
+			allErrs = append(allErrs, field.Duplicate(fldPath.Index(i), fallback))
+			continue
+		}
+		seen.Insert(fallback)
+	}
+
+	return allErrs
+}
+
 func validateFirewallSpecUpdate(fOld, fNew *v2.FirewallSpec, fldPath *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
 
@@ -114,6 +114,19 @@ func Test_firewallValidator_ValidateCreate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "invalid fallbacks",
+			mutateFn: func(f *v2.Firewall) *v2.Firewall {
+				f.Spec.FallbackSizes = []string{"n2-medium-x86", ""}
+				f.Spec.FallbackPartitions = []string{f.Spec.Partition}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall-123" is invalid: [spec.fallbackSizes[1]: Invalid value: "": fallback must not be empty, spec.fallbackPartitions[0]: Duplicate value: "partition-a"]`,
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
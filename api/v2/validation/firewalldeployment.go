@@ -3,13 +3,19 @@ package validation
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/go-logr/logr"
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apivalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -47,11 +53,13 @@ func (v *firewallDeploymentValidator) ValidateUpdate(ctx context.Context, oldF,
 	allErrs = append(allErrs, apivalidation.ValidateObjectMetaAccessorUpdate(&newF.ObjectMeta, &oldF.ObjectMeta, field.NewPath("metadata"))...)
 	allErrs = append(allErrs, v.validateSpecUpdate(v.log, &oldF.Spec, &newF.Spec, &newF.Status, field.NewPath("spec"))...)
 
+	warnings := rolloutWarnings(oldF, newF)
+
 	if len(allErrs) == 0 {
-		return nil, nil
+		return warnings, nil
 	}
 
-	return nil, apierrors.NewInvalid(
+	return warnings, apierrors.NewInvalid(
 		newF.GetObjectKind().GroupVersionKind().GroupKind(),
 		newF.GetName(),
 		allErrs,
@@ -66,11 +74,15 @@ func (*firewallDeploymentValidator) validateSpec(f *v2.FirewallDeploymentSpec, f
 	var allErrs field.ErrorList
 
 	switch f.Strategy {
-	case v2.StrategyRecreate, v2.StrategyRollingUpdate:
+	case v2.StrategyRecreate, v2.StrategyRollingUpdate, v2.StrategyCanary, v2.StrategyAuto:
 	default:
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("strategy"), f.Strategy, fmt.Sprintf("unknown strategy: %s", f.Strategy)))
 	}
 
+	if f.Canary != nil && f.Canary.SoakDuration != nil && f.Canary.SoakDuration.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("canary", "soakDuration"), f.Canary.SoakDuration.Duration.String(), "soak duration cannot be negative"))
+	}
+
 	if f.Replicas < 0 {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), f.Replicas, "replicas cannot be a negative number"))
 	}
@@ -78,6 +90,65 @@ func (*firewallDeploymentValidator) validateSpec(f *v2.FirewallDeploymentSpec, f
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), f.Replicas, fmt.Sprintf("no more than %d firewall replicas are allowed", v2.FirewallMaxReplicas)))
 	}
 
+	if f.Spares < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("spares"), f.Spares, "spares cannot be a negative number"))
+	}
+	if f.Spares > v2.FirewallMaxReplicas {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("spares"), f.Spares, fmt.Sprintf("no more than %d spare firewalls are allowed", v2.FirewallMaxReplicas)))
+	}
+
+	if f.ProgressDeadline != nil && f.ProgressDeadline.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("progressDeadline"), f.ProgressDeadline.Duration.String(), "progress deadline must be greater than zero"))
+	}
+	allErrs = append(allErrs, validateTimeouts(f.CreateTimeout, f.HealthTimeout, fldPath)...)
+	allErrs = append(allErrs, validateMinAvailable(f.MinAvailable, fldPath.Child("minAvailable"))...)
+	allErrs = append(allErrs, validateRecreationBudget(f.RecreationBudget, fldPath.Child("recreationBudget"))...)
+	allErrs = append(allErrs, validateQuarantine(f.Quarantine, fldPath.Child("quarantine"))...)
+	allErrs = append(allErrs, validateDriftRemediation(f.DriftRemediation, fldPath.Child("driftRemediation"))...)
+
+	if ru := f.RollingUpdate; ru != nil {
+		if ru.MaxSurge != nil && *ru.MaxSurge < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("rollingUpdate", "maxSurge"), *ru.MaxSurge, "max surge cannot be a negative number"))
+		}
+		if ru.MaxUnavailable != nil && *ru.MaxUnavailable < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("rollingUpdate", "maxUnavailable"), *ru.MaxUnavailable, "max unavailable cannot be a negative number"))
+		}
+		if ru.MaxSurge != nil && ru.MaxUnavailable != nil && *ru.MaxSurge == 0 && *ru.MaxUnavailable == 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("rollingUpdate"), fmt.Sprintf("maxSurge: %d, maxUnavailable: %d", *ru.MaxSurge, *ru.MaxUnavailable), "max surge and max unavailable cannot both be zero"))
+		}
+		// without surge, firewalls of the old sets are removed before a new firewall exists that traffic could be shifted to
+		if ru.MaxSurge != nil && *ru.MaxSurge == 0 && (f.StepwiseTrafficShift || (f.Hooks != nil && len(f.Hooks.PreRollout) > 0)) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("rollingUpdate", "maxSurge"), *ru.MaxSurge, "max surge must be greater than zero when using stepwise traffic shift or pre-rollout hooks"))
+		}
+	}
+
+	if f.StepwiseTrafficShift && f.Strategy != v2.StrategyRollingUpdate && f.Strategy != v2.StrategyAuto {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("stepwiseTrafficShift"), f.StepwiseTrafficShift, fmt.Sprintf("stepwise traffic shift is only supported by the %s and %s strategies", v2.StrategyRollingUpdate, v2.StrategyAuto)))
+	}
+
+	if f.Hooks != nil && (len(f.Hooks.PreRollout) > 0 || len(f.Hooks.PostRollout) > 0) && f.Strategy != v2.StrategyRollingUpdate && f.Strategy != v2.StrategyAuto {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("hooks"), f.Strategy, fmt.Sprintf("rollout hooks are only supported by the %s and %s strategies", v2.StrategyRollingUpdate, v2.StrategyAuto)))
+	}
+
+	if f.Hooks != nil {
+		allErrs = append(allErrs, validateRolloutHooks(f.Hooks.PreRollout, fldPath.Child("hooks", "preRollout"))...)
+		allErrs = append(allErrs, validateRolloutHooks(f.Hooks.PostRollout, fldPath.Child("hooks", "postRollout"))...)
+	}
+
+	allErrs = append(allErrs, validateStatusSinks(f.StatusSinks, fldPath.Child("statusSinks"))...)
+
+	if constraint := f.AutoUpdate.MachineImageConstraint; constraint != "" && constraint != v2.MachineImageConstraintPatchOnly {
+		if _, err := semver.NewConstraint(constraint); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("autoUpdate", "machineImageConstraint"), constraint, fmt.Sprintf("constraint must either be %q or a semantic version constraint: %s", v2.MachineImageConstraintPatchOnly, err)))
+		}
+	}
+
+	allErrs = append(allErrs, validateMaintenanceWindows(f.AutoUpdate.MaintenanceWindows, fldPath.Child("autoUpdate", "maintenanceWindows"))...)
+
+	if f.RevisionHistoryLimit != nil && *f.RevisionHistoryLimit < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("revisionHistoryLimit"), *f.RevisionHistoryLimit, "revision history limit cannot be a negative number"))
+	}
+
 	if f.Selector == nil {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("selector"), f.Selector, "selector should not be nil"))
 	} else {
@@ -119,3 +190,150 @@ func (v *firewallDeploymentValidator) validateSpecUpdate(log logr.Logger, oldF,
 
 	return allErrs
 }
+
+// rolloutWarnings warns about template changes that replace all firewalls of the deployment, such that users
+// do not find out about a roll only after it has started.
+func rolloutWarnings(oldF, newF *v2.FirewallDeployment) admission.Warnings {
+	reasons := v2.FirewallRollReasons(&oldF.Spec.Template.Spec, &newF.Spec.Template.Spec)
+	if len(reasons) == 0 || newF.Spec.Replicas == 0 {
+		return nil
+	}
+
+	noun := "firewalls"
+	if newF.Spec.Replicas == 1 {
+		noun = "firewall"
+	}
+
+	warning := fmt.Sprintf("this change will roll %d %s (%s)", newF.Spec.Replicas, noun, strings.Join(reasons, ", "))
+
+	switch {
+	case newF.Spec.Paused:
+		warning += ", the rollout starts when the deployment is resumed"
+	case newF.Spec.Strategy == v2.StrategyRecreate:
+		warning += ", the existing firewalls are deleted before the new firewalls are created"
+	}
+
+	return admission.Warnings{warning}
+}
+
+func validateRolloutHooks(hooks []v2.FirewallRolloutHook, fldPath *field.Path) field.ErrorList {
+	var (
+		allErrs field.ErrorList
+		names   = sets.New[string]()
+	)
+
+	for i, hook := range hooks {
+		idxPath := fldPath.Index(i)
+
+		if hook.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), "hook name is required"))
+		} else if names.Has(hook.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), hook.Name))
+		} else if errs := utilvalidation.IsDNS1123Label(hook.Name); len(errs) > 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("name"), hook.Name, strings.Join(errs, ", ")))
+		}
+		names.Insert(hook.Name)
+
+		if (hook.HTTP == nil) == (hook.Job == nil) {
+			allErrs = append(allErrs, field.Invalid(idxPath, hook.Name, "exactly one of http or job needs to be specified"))
+		}
+
+		if hook.HTTP != nil {
+			if _, err := url.ParseRequestURI(hook.HTTP.URL); err != nil {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("http", "url"), hook.HTTP.URL, fmt.Sprintf("url is invalid: %s", err)))
+			}
+		}
+
+		if hook.Job != nil && hook.Job.CronJobName == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("job", "cronJobName"), "cron job name is required"))
+		}
+	}
+
+	return allErrs
+}
+
+func validateStatusSinks(sinks []v2.FirewallStatusSink, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, sink := range sinks {
+		idxPath := fldPath.Index(i)
+
+		specified := 0
+		for _, ok := range []bool{sink.ConfigMap != nil, sink.Deployment != nil, sink.Resource != nil} {
+			if ok {
+				specified++
+			}
+		}
+		if specified != 1 {
+			allErrs = append(allErrs, field.Invalid(idxPath, specified, "exactly one of configMap, deployment or resource needs to be specified"))
+		}
+
+		if cm := sink.ConfigMap; cm != nil {
+			if errs := utilvalidation.IsDNS1123Subdomain(cm.Name); len(errs) > 0 {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("configMap", "name"), cm.Name, strings.Join(errs, ", ")))
+			}
+			if cm.Key != "" {
+				if errs := utilvalidation.IsConfigMapKey(cm.Key); len(errs) > 0 {
+					allErrs = append(allErrs, field.Invalid(idxPath.Child("configMap", "key"), cm.Key, strings.Join(errs, ", ")))
+				}
+			}
+		}
+
+		if res := sink.Resource; res != nil {
+			if res.APIVersion == "" {
+				allErrs = append(allErrs, field.Required(idxPath.Child("resource", "apiVersion"), "api version is required"))
+			}
+			if res.Kind == "" {
+				allErrs = append(allErrs, field.Required(idxPath.Child("resource", "kind"), "kind is required"))
+			}
+			if res.Name == "" {
+				allErrs = append(allErrs, field.Required(idxPath.Child("resource", "name"), "name is required"))
+			}
+			if _, err := res.Fields(); err != nil {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("resource", "jsonPath"), res.JSONPath, err.Error()))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func validateMaintenanceWindows(windows []v2.FirewallMaintenanceWindow, fldPath *field.Path) field.ErrorList {
+	var (
+		allErrs  field.ErrorList
+		weekdays = sets.New[string]()
+	)
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		weekdays.Insert(strings.ToLower(d.String()))
+	}
+
+	for i, w := range windows {
+		idxPath := fldPath.Index(i)
+
+		for j, day := range w.Weekdays {
+			if !weekdays.Has(strings.ToLower(day)) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("weekdays").Index(j), day, "unknown weekday"))
+			}
+		}
+
+		if _, err := time.Parse("15:04", w.Begin); err != nil {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("begin"), w.Begin, "begin must be in the format HH:MM"))
+		}
+		if _, err := time.Parse("15:04", w.End); err != nil {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("end"), w.End, "end must be in the format HH:MM"))
+		}
+
+		if w.Begin == w.End {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("end"), w.End, "end must differ from begin"))
+		}
+
+		if w.Timezone != "" {
+			if _, err := time.LoadLocation(w.Timezone); err != nil {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("timezone"), w.Timezone, fmt.Sprintf("unknown timezone: %s", err)))
+			}
+		}
+	}
+
+	return allErrs
+}
@@ -3,6 +3,7 @@ package validation
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr/testr"
 	"github.com/google/go-cmp/cmp"
@@ -11,6 +12,7 @@ import (
 	"github.com/metal-stack/metal-lib/pkg/testcommon"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 func Test_firewallDeploymentValidator_ValidateCreate(t *testing.T) {
@@ -83,6 +85,324 @@ func Test_firewallDeploymentValidator_ValidateCreate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "canary strategy is valid",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.Strategy = v2.StrategyCanary
+				f.Spec.Canary = &v2.FirewallCanary{
+					SoakDuration: &metav1.Duration{Duration: 5 * time.Minute},
+				}
+				return f
+			},
+			wantErr: nil,
+		},
+		{
+			name: "unknown drift remediation",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.DriftRemediation = "Delete"
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.driftRemediation: Invalid value: "Delete": unknown drift remediation: Delete`,
+				},
+			},
+		},
+		{
+			name: "status sinks are valid",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.StatusSinks = []v2.FirewallStatusSink{
+					{ConfigMap: &v2.FirewallConfigMapStatusSink{Name: "egress"}},
+					{Deployment: &v2.FirewallDeploymentStatusSink{}},
+					{Resource: &v2.FirewallResourceStatusSink{APIVersion: "example.com/v1", Kind: "Cluster", Name: "c", JSONPath: "{.status.egressCIDRs}"}},
+				}
+				return f
+			},
+			wantErr: nil,
+		},
+		{
+			name: "status sink with invalid json path and multiple sink types",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.StatusSinks = []v2.FirewallStatusSink{
+					{
+						Deployment: &v2.FirewallDeploymentStatusSink{},
+						Resource:   &v2.FirewallResourceStatusSink{APIVersion: "example.com/v1", Kind: "Cluster", Name: "c", JSONPath: ".status.cidrs[0]"},
+					},
+				}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: [spec.statusSinks[0]: Invalid value: 2: exactly one of configMap, deployment or resource needs to be specified, spec.statusSinks[0].resource.jsonPath: Invalid value: ".status.cidrs[0]": json path must only contain simple fields]`,
+				},
+			},
+		},
+		{
+			name: "max surge and max unavailable are both zero",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.RollingUpdate = &v2.FirewallRollingUpdate{
+					MaxSurge:       new(0),
+					MaxUnavailable: new(0),
+				}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.rollingUpdate: Invalid value: "maxSurge: 0, maxUnavailable: 0": max surge and max unavailable cannot both be zero`,
+				},
+			},
+		},
+		{
+			name: "rollout hook with http and job",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.Hooks = &v2.FirewallRolloutHooks{
+					PreRollout: []v2.FirewallRolloutHook{
+						{
+							Name: "smoke-test",
+							HTTP: &v2.FirewallRolloutHTTPHook{
+								URL: "http://smoke-test",
+							},
+							Job: &v2.FirewallRolloutJobHook{
+								CronJobName: "smoke-test",
+							},
+						},
+					},
+				}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.hooks.preRollout[0]: Invalid value: "smoke-test": exactly one of http or job needs to be specified`,
+				},
+			},
+		},
+		{
+			name: "stepwise traffic shift without max surge",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.StepwiseTrafficShift = true
+				f.Spec.RollingUpdate = &v2.FirewallRollingUpdate{
+					MaxSurge:       new(0),
+					MaxUnavailable: new(1),
+				}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.rollingUpdate.maxSurge: Invalid value: 0: max surge must be greater than zero when using stepwise traffic shift or pre-rollout hooks`,
+				},
+			},
+		},
+		{
+			name: "stepwise traffic shift with canary strategy",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.Strategy = v2.StrategyCanary
+				f.Spec.StepwiseTrafficShift = true
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.stepwiseTrafficShift: Invalid value: true: stepwise traffic shift is only supported by the RollingUpdate and Auto strategies`,
+				},
+			},
+		},
+		{
+			name: "stepwise traffic shift with auto strategy",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.Strategy = v2.StrategyAuto
+				f.Spec.StepwiseTrafficShift = true
+				return f
+			},
+			wantErr: nil,
+		},
+		{
+			name: "hooks with canary strategy",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.Strategy = v2.StrategyCanary
+				f.Spec.Hooks = &v2.FirewallRolloutHooks{
+					PostRollout: []v2.FirewallRolloutHook{
+						{
+							Name: "notify",
+							HTTP: &v2.FirewallRolloutHTTPHook{URL: "http://example.com/notify"},
+						},
+					},
+				}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.hooks: Invalid value: "Canary": rollout hooks are only supported by the RollingUpdate and Auto strategies`,
+				},
+			},
+		},
+		{
+			name: "hooks with auto strategy",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.Strategy = v2.StrategyAuto
+				f.Spec.Hooks = &v2.FirewallRolloutHooks{
+					PostRollout: []v2.FirewallRolloutHook{
+						{
+							Name: "notify",
+							HTTP: &v2.FirewallRolloutHTTPHook{URL: "http://example.com/notify"},
+						},
+					},
+				}
+				return f
+			},
+			wantErr: nil,
+		},
+		{
+			name: "negative revision history limit",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.RevisionHistoryLimit = new(-1)
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.revisionHistoryLimit: Invalid value: -1: revision history limit cannot be a negative number`,
+				},
+			},
+		},
+		{
+			name: "patch-only machine image constraint is valid",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.AutoUpdate.MachineImageConstraint = v2.MachineImageConstraintPatchOnly
+				return f
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invalid machine image constraint",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.AutoUpdate.MachineImageConstraint = "minor-only"
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.autoUpdate.machineImageConstraint: Invalid value: "minor-only": constraint must either be "patch-only" or a semantic version constraint: improper constraint: minor-only`,
+				},
+			},
+		},
+		{
+			name: "invalid maintenance window",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.AutoUpdate.MaintenanceWindows = []v2.FirewallMaintenanceWindow{
+					{
+						Weekdays: []string{"Monday", "Funday"},
+						Begin:    "22:00",
+						End:      "2:00am",
+						Timezone: "Europe/Berlin",
+					},
+				}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: [spec.autoUpdate.maintenanceWindows[0].weekdays[1]: Invalid value: "Funday": unknown weekday, spec.autoUpdate.maintenanceWindows[0].end: Invalid value: "2:00am": end must be in the format HH:MM]`,
+				},
+			},
+		},
+		{
+			name: "negative canary soak duration",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.Strategy = v2.StrategyCanary
+				f.Spec.Canary = &v2.FirewallCanary{
+					SoakDuration: &metav1.Duration{Duration: -5 * time.Minute},
+				}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.canary.soakDuration: Invalid value: "-5m0s": soak duration cannot be negative`,
+				},
+			},
+		},
+		{
+			name: "zero progress deadline",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.ProgressDeadline = &metav1.Duration{}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.progressDeadline: Invalid value: "0s": progress deadline must be greater than zero`,
+				},
+			},
+		},
+		{
+			name: "negative timeouts",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.ProgressDeadline = &metav1.Duration{Duration: -5 * time.Minute}
+				f.Spec.CreateTimeout = &metav1.Duration{Duration: -10 * time.Minute}
+				f.Spec.HealthTimeout = &metav1.Duration{Duration: 20 * time.Minute}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: [spec.progressDeadline: Invalid value: "-5m0s": progress deadline must be greater than zero, spec.createTimeout: Invalid value: "-10m0s": create timeout cannot be negative]`,
+				},
+			},
+		},
+		{
+			name: "negative min available",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.MinAvailable = new(-1)
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.minAvailable: Invalid value: -1: min available cannot be a negative number`,
+				},
+			},
+		},
+		{
+			name: "invalid recreation budget",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.RecreationBudget = &v2.FirewallRecreationBudget{
+					MaxRecreations: 0,
+					Window:         &metav1.Duration{},
+				}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: [spec.recreationBudget.maxRecreations: Invalid value: 0: max recreations must be greater than zero, spec.recreationBudget.window: Invalid value: "0s": window must be greater than zero]`,
+				},
+			},
+		},
+		{
+			name: "invalid quarantine ttl",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.Quarantine = &v2.FirewallQuarantine{
+					TTL: &metav1.Duration{Duration: -time.Hour},
+				}
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.quarantine.ttl: Invalid value: "-1h0m0s": ttl must be greater than zero`,
+				},
+			},
+		},
+		{
+			name: "auto strategy is valid",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.Strategy = v2.StrategyAuto
+				return f
+			},
+			wantErr: nil,
+		},
+		{
+			name: "negative spares",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.Spec.Spares = -1
+				return f
+			},
+			wantErr: &apierrors.StatusError{
+				ErrStatus: metav1.Status{
+					Message: ` "firewall" is invalid: spec.spares: Invalid value: -1: spares cannot be a negative number`,
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -145,9 +465,10 @@ func Test_firewallDeploymentValidator_ValidateUpdate(t *testing.T) {
 	}
 
 	tests := []struct {
-		name     string
-		mutateFn func(f *v2.FirewallDeployment) *v2.FirewallDeployment
-		wantErr  error
+		name         string
+		mutateFn     func(f *v2.FirewallDeployment) *v2.FirewallDeployment
+		wantErr      error
+		wantWarnings admission.Warnings
 	}{
 		{
 			name: "valid",
@@ -185,15 +506,40 @@ func Test_firewallDeploymentValidator_ValidateUpdate(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "warn about roll on image update",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.ResourceVersion = "1"
+				f.Spec.Replicas = 2
+				f.Spec.Template.Spec.Image = "image-b"
+				return f
+			},
+			wantWarnings: admission.Warnings{`this change will roll 2 firewalls (image changed from "image-a" to "image-b")`},
+		},
+		{
+			name: "warn about roll of paused deployment",
+			mutateFn: func(f *v2.FirewallDeployment) *v2.FirewallDeployment {
+				f.ResourceVersion = "1"
+				f.Spec.Replicas = 1
+				f.Spec.Paused = true
+				f.Spec.Template.Spec.Size = "size-b"
+				f.Spec.Template.Spec.Networks = []string{"internet", "mpls"}
+				return f
+			},
+			wantWarnings: admission.Warnings{`this change will roll 1 firewall (size changed from "size-a" to "size-b", networks changed from "internet" to "internet,mpls"), the rollout starts when the deployment is resumed`},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			v := NewFirewallDeploymentValidator(testr.New(t))
 
-			_, got := v.ValidateUpdate(context.Background(), valid.DeepCopy(), tt.mutateFn(valid.DeepCopy()))
+			warnings, got := v.ValidateUpdate(context.Background(), valid.DeepCopy(), tt.mutateFn(valid.DeepCopy()))
 			if diff := cmp.Diff(tt.wantErr, got, testcommon.ErrorStringComparer()); diff != "" {
 				t.Errorf("error diff (+got -want):\n %s", diff)
 			}
+			if diff := cmp.Diff(tt.wantWarnings, warnings); diff != "" {
+				t.Errorf("warnings diff (+got -want):\n %s", diff)
+			}
 		})
 	}
 }
@@ -73,6 +73,11 @@ func (v *firewallSetValidator) validateSpec(f *v2.FirewallSetSpec, fldPath *fiel
 	}
 
 	allErrs = append(allErrs, validateDistance(f.Distance, fldPath.Child("distance"))...)
+	allErrs = append(allErrs, validateTimeouts(f.CreateTimeout, f.HealthTimeout, fldPath)...)
+	allErrs = append(allErrs, validateMinAvailable(f.MinAvailable, fldPath.Child("minAvailable"))...)
+	allErrs = append(allErrs, validateRecreationBudget(f.RecreationBudget, fldPath.Child("recreationBudget"))...)
+	allErrs = append(allErrs, validateQuarantine(f.Quarantine, fldPath.Child("quarantine"))...)
+	allErrs = append(allErrs, validateDriftRemediation(f.DriftRemediation, fldPath.Child("driftRemediation"))...)
 
 	if f.Selector == nil {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("selector"), f.Selector, "selector should not be nil"))
@@ -110,3 +115,65 @@ func (v *firewallSetValidator) validateSpecUpdate(log logr.Logger, oldF, newF *v
 
 	return allErrs
 }
+
+func validateTimeouts(createTimeout, healthTimeout *metav1.Duration, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if createTimeout != nil && createTimeout.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("createTimeout"), createTimeout.Duration.String(), "create timeout cannot be negative"))
+	}
+	if healthTimeout != nil && healthTimeout.Duration < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("healthTimeout"), healthTimeout.Duration.String(), "health timeout cannot be negative"))
+	}
+
+	return allErrs
+}
+
+func validateMinAvailable(minAvailable *int, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if minAvailable != nil && *minAvailable < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath, *minAvailable, "min available cannot be a negative number"))
+	}
+
+	return allErrs
+}
+
+func validateRecreationBudget(budget *v2.FirewallRecreationBudget, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if budget == nil {
+		return allErrs
+	}
+
+	if budget.MaxRecreations < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxRecreations"), budget.MaxRecreations, "max recreations must be greater than zero"))
+	}
+	if budget.Window != nil && budget.Window.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("window"), budget.Window.Duration.String(), "window must be greater than zero"))
+	}
+
+	return allErrs
+}
+
+func validateQuarantine(quarantine *v2.FirewallQuarantine, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if quarantine != nil && quarantine.TTL != nil && quarantine.TTL.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("ttl"), quarantine.TTL.Duration.String(), "ttl must be greater than zero"))
+	}
+
+	return allErrs
+}
+
+func validateDriftRemediation(remediation v2.FirewallDriftRemediation, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	switch remediation {
+	case "", v2.DriftRemediationNone, v2.DriftRemediationRollSet:
+	default:
+		allErrs = append(allErrs, field.Invalid(fldPath, remediation, fmt.Sprintf("unknown drift remediation: %s", remediation)))
+	}
+
+	return allErrs
+}
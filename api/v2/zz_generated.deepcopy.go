@@ -5,6 +5,7 @@
 package v2
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 	timex "time"
 )
@@ -212,6 +213,13 @@ func (in *Firewall) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallAutoUpdate) DeepCopyInto(out *FirewallAutoUpdate) {
 	*out = *in
+	if in.MaintenanceWindows != nil {
+		in, out := &in.MaintenanceWindows, &out.MaintenanceWindows
+		*out = make([]FirewallMaintenanceWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallAutoUpdate.
@@ -224,6 +232,41 @@ func (in *FirewallAutoUpdate) DeepCopy() *FirewallAutoUpdate {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallCanary) DeepCopyInto(out *FirewallCanary) {
+	*out = *in
+	if in.SoakDuration != nil {
+		in, out := &in.SoakDuration, &out.SoakDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallCanary.
+func (in *FirewallCanary) DeepCopy() *FirewallCanary {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallCanary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallConfigMapStatusSink) DeepCopyInto(out *FirewallConfigMapStatusSink) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallConfigMapStatusSink.
+func (in *FirewallConfigMapStatusSink) DeepCopy() *FirewallConfigMapStatusSink {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallConfigMapStatusSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallDeployment) DeepCopyInto(out *FirewallDeployment) {
 	*out = *in
@@ -286,7 +329,64 @@ func (in *FirewallDeploymentList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallDeploymentSpec) DeepCopyInto(out *FirewallDeploymentSpec) {
 	*out = *in
-	out.AutoUpdate = in.AutoUpdate
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(FirewallCanary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(FirewallRollingUpdate)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Hooks != nil {
+		in, out := &in.Hooks, &out.Hooks
+		*out = new(FirewallRolloutHooks)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int)
+		**out = **in
+	}
+	if in.ProgressDeadline != nil {
+		in, out := &in.ProgressDeadline, &out.ProgressDeadline
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.CreateTimeout != nil {
+		in, out := &in.CreateTimeout, &out.CreateTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.HealthTimeout != nil {
+		in, out := &in.HealthTimeout, &out.HealthTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(int)
+		**out = **in
+	}
+	if in.RecreationBudget != nil {
+		in, out := &in.RecreationBudget, &out.RecreationBudget
+		*out = new(FirewallRecreationBudget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Quarantine != nil {
+		in, out := &in.Quarantine, &out.Quarantine
+		*out = new(FirewallQuarantine)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StatusSinks != nil {
+		in, out := &in.StatusSinks, &out.StatusSinks
+		*out = make([]FirewallStatusSink, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.AutoUpdate.DeepCopyInto(&out.AutoUpdate)
 	if in.Selector != nil {
 		in, out := &in.Selector, &out.Selector
 		*out = make(map[string]string, len(*in))
@@ -310,6 +410,28 @@ func (in *FirewallDeploymentSpec) DeepCopy() *FirewallDeploymentSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallDeploymentStatus) DeepCopyInto(out *FirewallDeploymentStatus) {
 	*out = *in
+	if in.EgressIPs != nil {
+		in, out := &in.EgressIPs, &out.EgressIPs
+		*out = make([]EgressRuleSNAT, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReleasingEgressIPs != nil {
+		in, out := &in.ReleasingEgressIPs, &out.ReleasingEgressIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.EgressCIDRs != nil {
+		in, out := &in.EgressCIDRs, &out.EgressCIDRs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RolloutPlan != nil {
+		in, out := &in.RolloutPlan, &out.RolloutPlan
+		*out = new(FirewallRolloutPlan)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Conditions != nil {
 		in, out := &in.Conditions, &out.Conditions
 		*out = make(Conditions, len(*in))
@@ -329,6 +451,21 @@ func (in *FirewallDeploymentStatus) DeepCopy() *FirewallDeploymentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallDeploymentStatusSink) DeepCopyInto(out *FirewallDeploymentStatusSink) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallDeploymentStatusSink.
+func (in *FirewallDeploymentStatusSink) DeepCopy() *FirewallDeploymentStatusSink {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallDeploymentStatusSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallList) DeepCopyInto(out *FirewallList) {
 	*out = *in
@@ -361,6 +498,26 @@ func (in *FirewallList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallMaintenanceWindow) DeepCopyInto(out *FirewallMaintenanceWindow) {
+	*out = *in
+	if in.Weekdays != nil {
+		in, out := &in.Weekdays, &out.Weekdays
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallMaintenanceWindow.
+func (in *FirewallMaintenanceWindow) DeepCopy() *FirewallMaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallMaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallMonitor) DeepCopyInto(out *FirewallMonitor) {
 	*out = *in
@@ -507,13 +664,228 @@ func (in *FirewallNetwork) DeepCopy() *FirewallNetwork {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallQuarantine) DeepCopyInto(out *FirewallQuarantine) {
+	*out = *in
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallQuarantine.
+func (in *FirewallQuarantine) DeepCopy() *FirewallQuarantine {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallQuarantine)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallRecreation) DeepCopyInto(out *FirewallRecreation) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallRecreation.
+func (in *FirewallRecreation) DeepCopy() *FirewallRecreation {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallRecreation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallRecreationBudget) DeepCopyInto(out *FirewallRecreationBudget) {
+	*out = *in
+	if in.Window != nil {
+		in, out := &in.Window, &out.Window
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallRecreationBudget.
+func (in *FirewallRecreationBudget) DeepCopy() *FirewallRecreationBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallRecreationBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallResourceStatusSink) DeepCopyInto(out *FirewallResourceStatusSink) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallResourceStatusSink.
+func (in *FirewallResourceStatusSink) DeepCopy() *FirewallResourceStatusSink {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallResourceStatusSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallRollingUpdate) DeepCopyInto(out *FirewallRollingUpdate) {
+	*out = *in
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallRollingUpdate.
+func (in *FirewallRollingUpdate) DeepCopy() *FirewallRollingUpdate {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallRollingUpdate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallRolloutHTTPHook) DeepCopyInto(out *FirewallRolloutHTTPHook) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallRolloutHTTPHook.
+func (in *FirewallRolloutHTTPHook) DeepCopy() *FirewallRolloutHTTPHook {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallRolloutHTTPHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallRolloutHook) DeepCopyInto(out *FirewallRolloutHook) {
+	*out = *in
+	if in.HTTP != nil {
+		in, out := &in.HTTP, &out.HTTP
+		*out = new(FirewallRolloutHTTPHook)
+		**out = **in
+	}
+	if in.Job != nil {
+		in, out := &in.Job, &out.Job
+		*out = new(FirewallRolloutJobHook)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallRolloutHook.
+func (in *FirewallRolloutHook) DeepCopy() *FirewallRolloutHook {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallRolloutHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallRolloutHooks) DeepCopyInto(out *FirewallRolloutHooks) {
+	*out = *in
+	if in.PreRollout != nil {
+		in, out := &in.PreRollout, &out.PreRollout
+		*out = make([]FirewallRolloutHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostRollout != nil {
+		in, out := &in.PostRollout, &out.PostRollout
+		*out = make([]FirewallRolloutHook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallRolloutHooks.
+func (in *FirewallRolloutHooks) DeepCopy() *FirewallRolloutHooks {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallRolloutHooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallRolloutJobHook) DeepCopyInto(out *FirewallRolloutJobHook) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallRolloutJobHook.
+func (in *FirewallRolloutJobHook) DeepCopy() *FirewallRolloutJobHook {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallRolloutJobHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallRolloutPlan) DeepCopyInto(out *FirewallRolloutPlan) {
+	*out = *in
+	if in.Reasons != nil {
+		in, out := &in.Reasons, &out.Reasons
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NewSetDistance != nil {
+		in, out := &in.NewSetDistance, &out.NewSetDistance
+		*out = new(FirewallDistance)
+		**out = **in
+	}
+	if in.SetsToDelete != nil {
+		in, out := &in.SetsToDelete, &out.SetsToDelete
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Distances != nil {
+		in, out := &in.Distances, &out.Distances
+		*out = make([]FirewallSetDistance, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallRolloutPlan.
+func (in *FirewallRolloutPlan) DeepCopy() *FirewallRolloutPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallRolloutPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallSet) DeepCopyInto(out *FirewallSet) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallSet.
@@ -534,6 +906,21 @@ func (in *FirewallSet) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallSetDistance) DeepCopyInto(out *FirewallSetDistance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallSetDistance.
+func (in *FirewallSetDistance) DeepCopy() *FirewallSetDistance {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallSetDistance)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallSetList) DeepCopyInto(out *FirewallSetList) {
 	*out = *in
@@ -577,6 +964,31 @@ func (in *FirewallSetSpec) DeepCopyInto(out *FirewallSetSpec) {
 		}
 	}
 	in.Template.DeepCopyInto(&out.Template)
+	if in.CreateTimeout != nil {
+		in, out := &in.CreateTimeout, &out.CreateTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.HealthTimeout != nil {
+		in, out := &in.HealthTimeout, &out.HealthTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MinAvailable != nil {
+		in, out := &in.MinAvailable, &out.MinAvailable
+		*out = new(int)
+		**out = **in
+	}
+	if in.RecreationBudget != nil {
+		in, out := &in.RecreationBudget, &out.RecreationBudget
+		*out = new(FirewallRecreationBudget)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Quarantine != nil {
+		in, out := &in.Quarantine, &out.Quarantine
+		*out = new(FirewallQuarantine)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallSetSpec.
@@ -592,6 +1004,20 @@ func (in *FirewallSetSpec) DeepCopy() *FirewallSetSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallSetStatus) DeepCopyInto(out *FirewallSetStatus) {
 	*out = *in
+	if in.Recreations != nil {
+		in, out := &in.Recreations, &out.Recreations
+		*out = make([]FirewallRecreation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallSetStatus.
@@ -607,6 +1033,16 @@ func (in *FirewallSetStatus) DeepCopy() *FirewallSetStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallSpec) DeepCopyInto(out *FirewallSpec) {
 	*out = *in
+	if in.FallbackSizes != nil {
+		in, out := &in.FallbackSizes, &out.FallbackSizes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FallbackPartitions != nil {
+		in, out := &in.FallbackPartitions, &out.FallbackPartitions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Networks != nil {
 		in, out := &in.Networks, &out.Networks
 		*out = make([]string, len(*in))
@@ -763,6 +1199,36 @@ func (in *FirewallStatusEvalResult) DeepCopy() *FirewallStatusEvalResult {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FirewallStatusSink) DeepCopyInto(out *FirewallStatusSink) {
+	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(FirewallConfigMapStatusSink)
+		**out = **in
+	}
+	if in.Deployment != nil {
+		in, out := &in.Deployment, &out.Deployment
+		*out = new(FirewallDeploymentStatusSink)
+		**out = **in
+	}
+	if in.Resource != nil {
+		in, out := &in.Resource, &out.Resource
+		*out = new(FirewallResourceStatusSink)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirewallStatusSink.
+func (in *FirewallStatusSink) DeepCopy() *FirewallStatusSink {
+	if in == nil {
+		return nil
+	}
+	out := new(FirewallStatusSink)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FirewallTemplateSpec) DeepCopyInto(out *FirewallTemplateSpec) {
 	*out = *in
@@ -0,0 +1,146 @@
+package deployment
+
+import (
+	"fmt"
+	"time"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/defaults"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// canaryStrategy first creates a new set with a single replica, waits until it was ready for the soak duration,
+// then scales it up to the desired amount of replicas and deletes the old one's when the new one becomes ready
+func (c *controller) canaryStrategy(r *controllers.Ctx[*v2.FirewallDeployment], ownedSets []*v2.FirewallSet, latestSet *v2.FirewallSet) error {
+	if c.isNewSetRequired(r, latestSet) {
+		r.Log.Info("significant changes detected in the spec, creating new canary firewall set", "distance", v2.FirewallRollingUpdateSetDistance)
+
+		newSet, err := c.createNextFirewallSet(r, latestSet, &setOverrides{
+			distance: v2.FirewallRollingUpdateSetDistance.Pointer(),
+			replicas: new(min(1, r.Target.Spec.Replicas)),
+		})
+		if err != nil {
+			return err
+		}
+
+		c.recorder.Eventf(newSet, nil, corev1.EventTypeNormal, "Create", "creating canary set", "created canary firewall set %s", newSet.Name)
+
+		ownedSets = append(ownedSets, newSet)
+
+		return c.cleanupIntermediateSets(r, ownedSets)
+	}
+
+	var (
+		oldSets      = controllers.Except(activeSets(ownedSets, latestSet), latestSet)
+		soakDuration = canarySoakDuration(r.Target)
+		ows          *setOverrides
+	)
+
+	if len(oldSets) > 0 && latestSet.Spec.Replicas < r.Target.Spec.Replicas {
+		// the canary was not promoted yet, so we must not scale it up
+		ows = &setOverrides{
+			replicas: &latestSet.Spec.Replicas,
+		}
+	}
+
+	err := c.syncFirewallSet(r, latestSet, ows)
+	if err != nil {
+		return fmt.Errorf("unable to update firewall set: %w", err)
+	}
+
+	if latestSet.Status.ReadyReplicas != latestSet.Spec.Replicas {
+		r.Log.Info("set replicas are not yet ready")
+
+		// the soak duration is part of the expected progress of a canary update
+		if time.Since(latestSet.CreationTimestamp.Time) > c.c.GetProgressDeadlineFor(r.Target)+soakDuration {
+			cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionFalse, "ProgressDeadlineExceeded", fmt.Sprintf("FirewallSet %q has timed out progressing.", latestSet.Name))
+			r.Target.Status.Conditions.Set(cond)
+
+			if r.Target.Spec.AutoRollback {
+				return c.autoRollback(r, ownedSets, latestSet)
+			}
+		}
+
+		return c.cleanupIntermediateSets(r, ownedSets)
+	}
+
+	if len(oldSets) == 0 {
+		setRolloutCompleted(r, latestSet)
+
+		return nil
+	}
+
+	remaining, err := c.canarySoakRemaining(r, latestSet, soakDuration)
+	if err != nil {
+		return err
+	}
+
+	if remaining > 0 {
+		r.Log.Info("canary set is soaking", "remaining", remaining.String())
+
+		cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionTrue, "CanarySoaking", fmt.Sprintf("Canary FirewallSet %q is ready and soaking for %s.", latestSet.Name, soakDuration.String()))
+		r.Target.Status.Conditions.Set(cond)
+
+		return controllers.RequeueAfter(remaining, "canary firewall set is soaking")
+	}
+
+	if latestSet.Spec.Replicas < r.Target.Spec.Replicas {
+		r.Log.Info("canary set has soaked successfully, scaling up", "replicas", r.Target.Spec.Replicas)
+
+		err := c.syncFirewallSet(r, latestSet, nil)
+		if err != nil {
+			return fmt.Errorf("unable to scale up canary firewall set: %w", err)
+		}
+
+		cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionTrue, "CanaryPromoted", fmt.Sprintf("Canary FirewallSet %q has soaked successfully and is scaled up.", latestSet.Name))
+		r.Target.Status.Conditions.Set(cond)
+
+		c.recorder.Eventf(latestSet, nil, corev1.EventTypeNormal, "Promote", "promoting canary set", "promoted canary firewall set %s", latestSet.Name)
+
+		return controllers.RequeueAfter(2*time.Second, "canary firewall set was promoted, waiting for scale up")
+	}
+
+	setRolloutCompleted(r, latestSet)
+
+	r.Log.Info("ensuring old sets are cleaned up")
+
+	return c.retireOldSets(r, ownedSets, latestSet)
+}
+
+// canarySoakRemaining returns the remaining time until the firewalls of the given set have been provisioned for the soak duration.
+func (c *controller) canarySoakRemaining(r *controllers.Ctx[*v2.FirewallDeployment], set *v2.FirewallSet, soakDuration time.Duration) (time.Duration, error) {
+	fws, _, err := controllers.GetOwnedResources(r.Ctx, c.c.GetSeedClient(), set.Spec.Selector, set, &v2.FirewallList{}, func(fl *v2.FirewallList) []*v2.Firewall {
+		return fl.GetItems()
+	})
+	if err != nil {
+		return 0, fmt.Errorf("unable to get owned firewalls: %w", err)
+	}
+
+	var provisionedSince *time.Time
+	for _, fw := range fws {
+		cond := fw.Status.Conditions.Get(v2.FirewallProvisioned)
+		if cond == nil || cond.Status != v2.ConditionTrue {
+			continue
+		}
+
+		if provisionedSince == nil || cond.LastTransitionTime.Time.Before(*provisionedSince) {
+			provisionedSince = &cond.LastTransitionTime.Time
+		}
+	}
+
+	if provisionedSince == nil {
+		// this should not happen as the set is ready, but we wait until the status of the firewalls catches up
+		return 10 * time.Second, nil
+	}
+
+	return max(0, time.Until(provisionedSince.Add(soakDuration))), nil
+}
+
+func canarySoakDuration(d *v2.FirewallDeployment) time.Duration {
+	if d.Spec.Canary == nil || d.Spec.Canary.SoakDuration == nil {
+		return defaults.DefaultCanarySoakDuration
+	}
+
+	return d.Spec.Canary.SoakDuration.Duration
+}
@@ -0,0 +1,226 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_controller_canaryStrategy(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		namespace = "test"
+	)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	newDeployment := func(image string) *v2.FirewallDeployment {
+		return &v2.FirewallDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fwdeploy",
+				Namespace: namespace,
+				UID:       "deploy-uid",
+			},
+			Spec: v2.FirewallDeploymentSpec{
+				Strategy: v2.StrategyCanary,
+				Replicas: 3,
+				Canary: &v2.FirewallCanary{
+					SoakDuration: &metav1.Duration{Duration: 10 * time.Minute},
+				},
+				Template: v2.FirewallTemplateSpec{
+					Spec: v2.FirewallSpec{
+						Image: image,
+					},
+				},
+			},
+		}
+	}
+
+	newSet := func(name string, revision string, image string, replicas, ready int, distance v2.FirewallDistance) *v2.FirewallSet {
+		deploy := newDeployment(image)
+		return &v2.FirewallSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				UID:       types.UID(name + "-uid"),
+				Annotations: map[string]string{
+					v2.RevisionAnnotation: revision,
+				},
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(deploy, v2.GroupVersion.WithKind("FirewallDeployment")),
+				},
+			},
+			Spec: v2.FirewallSetSpec{
+				Replicas: replicas,
+				Template: deploy.Spec.Template,
+				Distance: distance,
+			},
+			Status: v2.FirewallSetStatus{
+				ReadyReplicas: ready,
+			},
+		}
+	}
+
+	newProvisionedFirewall := func(set *v2.FirewallSet, since time.Time) *v2.Firewall {
+		return &v2.Firewall{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      set.Name + "-fw",
+				Namespace: namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(set, v2.GroupVersion.WithKind("FirewallSet")),
+				},
+			},
+			Status: v2.FirewallStatus{
+				Conditions: v2.Conditions{
+					{
+						Type:               v2.FirewallProvisioned,
+						Status:             v2.ConditionTrue,
+						LastTransitionTime: metav1.NewTime(since),
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		deployment  *v2.FirewallDeployment
+		sets        []*v2.FirewallSet
+		firewalls   []*v2.Firewall
+		wantRequeue bool
+		wantReason  string
+		validate    func(t *testing.T, c client.Client)
+	}{
+		{
+			name:       "creates canary set with a single replica on significant changes",
+			deployment: newDeployment("image-b"),
+			sets: []*v2.FirewallSet{
+				newSet("old", "0", "image-a", 3, 3, v2.FirewallShortestDistance),
+			},
+			wantReason: "NewFirewallSetCreated",
+			validate: func(t *testing.T, c client.Client) {
+				sets := &v2.FirewallSetList{}
+				require.NoError(t, c.List(ctx, sets, client.InNamespace(namespace)))
+				require.Len(t, sets.Items, 2)
+
+				for _, set := range sets.Items {
+					if set.Name == "old" {
+						continue
+					}
+
+					assert.Equal(t, 1, set.Spec.Replicas)
+					assert.Equal(t, v2.FirewallRollingUpdateSetDistance, set.Spec.Distance)
+					assert.Equal(t, "image-b", set.Spec.Template.Spec.Image)
+				}
+			},
+		},
+		{
+			name:       "canary set is soaking",
+			deployment: newDeployment("image-b"),
+			sets: []*v2.FirewallSet{
+				newSet("old", "0", "image-a", 3, 3, v2.FirewallShortestDistance),
+				newSet("canary", "1", "image-b", 1, 1, v2.FirewallRollingUpdateSetDistance),
+			},
+			firewalls: []*v2.Firewall{
+				newProvisionedFirewall(newSet("canary", "1", "image-b", 1, 1, v2.FirewallRollingUpdateSetDistance), time.Now().Add(-1*time.Minute)),
+			},
+			wantRequeue: true,
+			wantReason:  "CanarySoaking",
+			validate: func(t *testing.T, c client.Client) {
+				set := &v2.FirewallSet{}
+				require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "canary"}, set))
+				assert.Equal(t, 1, set.Spec.Replicas)
+
+				require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "old"}, set))
+				assert.Equal(t, 3, set.Spec.Replicas)
+			},
+		},
+		{
+			name:       "canary set is promoted after soaking",
+			deployment: newDeployment("image-b"),
+			sets: []*v2.FirewallSet{
+				newSet("old", "0", "image-a", 3, 3, v2.FirewallShortestDistance),
+				newSet("canary", "1", "image-b", 1, 1, v2.FirewallRollingUpdateSetDistance),
+			},
+			firewalls: []*v2.Firewall{
+				newProvisionedFirewall(newSet("canary", "1", "image-b", 1, 1, v2.FirewallRollingUpdateSetDistance), time.Now().Add(-11*time.Minute)),
+			},
+			wantRequeue: true,
+			wantReason:  "CanaryPromoted",
+			validate: func(t *testing.T, c client.Client) {
+				set := &v2.FirewallSet{}
+				require.NoError(t, c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: "canary"}, set))
+				assert.Equal(t, 3, set.Spec.Replicas)
+				assert.Equal(t, v2.FirewallRollingUpdateSetDistance, set.Spec.Distance)
+			},
+		},
+		{
+			name:       "old sets are deleted when promoted canary set is ready",
+			deployment: newDeployment("image-b"),
+			sets: []*v2.FirewallSet{
+				newSet("old", "0", "image-a", 3, 3, v2.FirewallShortestDistance),
+				newSet("canary", "1", "image-b", 3, 3, v2.FirewallRollingUpdateSetDistance),
+			},
+			firewalls: []*v2.Firewall{
+				newProvisionedFirewall(newSet("canary", "1", "image-b", 3, 3, v2.FirewallRollingUpdateSetDistance), time.Now().Add(-20*time.Minute)),
+			},
+			wantRequeue: true,
+			wantReason:  "NewFirewallSetAvailable",
+			validate: func(t *testing.T, c client.Client) {
+				sets := &v2.FirewallSetList{}
+				require.NoError(t, c.List(ctx, sets, client.InNamespace(namespace)))
+				require.Len(t, sets.Items, 1)
+				assert.Equal(t, "canary", sets.Items[0].Name)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objs []client.Object
+			for _, set := range tt.sets {
+				objs = append(objs, set)
+			}
+			for _, fw := range tt.firewalls {
+				objs = append(objs, fw)
+			}
+
+			c := newTestController(t, scheme, objs...)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: tt.deployment,
+			}
+
+			latestSet, err := controllers.MaxRevisionOf(tt.sets)
+			require.NoError(t, err)
+
+			err = c.canaryStrategy(r, tt.sets, latestSet)
+			if tt.wantRequeue {
+				require.Error(t, err)
+				assert.True(t, isRequeue(err), "expected requeue, got: %s", err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			cond := r.Target.Status.Conditions.Get(v2.FirewallDeploymentProgressing)
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+
+			if tt.validate != nil {
+				tt.validate(t, c.c.GetSeedClient())
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package deployment
+
+import (
+	"fmt"
+	"strings"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/metal-stack/metal-go/api/client/partition"
+	"github.com/metal-stack/metal-go/api/models"
+)
+
+// checkCapacity queries the metal-api for free machines that the firewalls of a new set can be allocated on and reports
+// the result in the capacity available condition. returns false only if it is known that there are not enough free
+// machines for the given amount of replicas.
+//
+// as the firewall controller falls back to other sizes and partitions if no machine is available, the fallbacks of the
+// template are considered as well.
+func (c *controller) checkCapacity(r *controllers.Ctx[*v2.FirewallDeployment], replicas int) bool {
+	if replicas == 0 {
+		return true
+	}
+
+	var (
+		spec       = r.Target.Spec.Template.Spec
+		sizes      = append([]string{spec.Size}, spec.FallbackSizes...)
+		partitions = append([]string{spec.Partition}, spec.FallbackPartitions...)
+	)
+
+	free, err := c.freeMachines(r, spec.Project, sizes, partitions)
+	if err != nil {
+		r.Log.Error(err, "unable to check capacity, continuing without capacity information")
+
+		cond := v2.NewCondition(v2.FirewallDeploymentCapacityAvailable, v2.ConditionUnknown, "CapacityUnknown", fmt.Sprintf("Unable to check capacity: %s", err))
+		r.Target.Status.Conditions.Set(cond)
+
+		return true
+	}
+
+	if free < replicas {
+		cond := v2.NewCondition(v2.FirewallDeploymentCapacityAvailable, v2.ConditionFalse, "NotEnoughCapacity", fmt.Sprintf("%d of %d required machines are free for sizes %s in partitions %s.", free, replicas, strings.Join(sizes, ","), strings.Join(partitions, ",")))
+		r.Target.Status.Conditions.Set(cond)
+
+		return false
+	}
+
+	cond := v2.NewCondition(v2.FirewallDeploymentCapacityAvailable, v2.ConditionTrue, "EnoughCapacity", fmt.Sprintf("%d of %d required machines are free for sizes %s in partitions %s.", free, replicas, strings.Join(sizes, ","), strings.Join(partitions, ",")))
+	r.Target.Status.Conditions.Set(cond)
+
+	return true
+}
+
+func (c *controller) freeMachines(r *controllers.Ctx[*v2.FirewallDeployment], project string, sizes, partitions []string) (int, error) {
+	var free int
+
+	for _, p := range partitions {
+		for _, size := range sizes {
+			resp, err := c.c.GetMetal().Partition().PartitionCapacity(partition.NewPartitionCapacityParams().WithBody(&models.V1PartitionCapacityRequest{
+				ID:        p,
+				Sizeid:    size,
+				Projectid: &project,
+			}).WithContext(r.Ctx), nil)
+			if err != nil {
+				return 0, fmt.Errorf("unable to query capacity of partition %q: %w", p, err)
+			}
+
+			for _, pc := range resp.Payload {
+				for _, server := range pc.Servers {
+					if server.Size != nil && *server.Size == size {
+						free += int(server.Free)
+					}
+				}
+			}
+		}
+	}
+
+	return free, nil
+}
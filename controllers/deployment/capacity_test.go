@@ -0,0 +1,233 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_controller_rollingUpdateStrategy_capacity(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	tests := []struct {
+		name          string
+		strategy      v2.FirewallUpdateStrategy
+		fallbackSizes []string
+		free          int32
+		wantCondition v2.ConditionStatus
+		wantRecreate  bool
+	}{
+		{
+			name:          "rolling update with enough capacity",
+			strategy:      v2.StrategyRollingUpdate,
+			free:          2,
+			wantCondition: v2.ConditionTrue,
+		},
+		{
+			name:          "rolling update without enough capacity still creates the new set",
+			strategy:      v2.StrategyRollingUpdate,
+			free:          1,
+			wantCondition: v2.ConditionFalse,
+		},
+		{
+			name:          "auto with enough capacity rolls",
+			strategy:      v2.StrategyAuto,
+			free:          2,
+			wantCondition: v2.ConditionTrue,
+		},
+		{
+			name:          "auto considers fallback sizes",
+			strategy:      v2.StrategyAuto,
+			fallbackSizes: []string{"size-b"},
+			free:          1,
+			wantCondition: v2.ConditionTrue,
+		},
+		{
+			name:          "auto without enough capacity recreates",
+			strategy:      v2.StrategyAuto,
+			free:          1,
+			wantCondition: v2.ConditionFalse,
+			wantRecreate:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy := &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fwdeploy",
+					Namespace: "test",
+					UID:       "deploy-uid",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Strategy: tt.strategy,
+					Replicas: 2,
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Image:         "b",
+							Size:          "size-a",
+							FallbackSizes: tt.fallbackSizes,
+							Partition:     "partition-a",
+							Project:       "project-a",
+						},
+					},
+				},
+			}
+
+			oldSet := newHistoryTestSet(deploy, 0, "a", 2)
+			oldSet.Spec.Template.Spec.Size = "size-a"
+			oldSet.Spec.Template.Spec.FallbackSizes = tt.fallbackSizes
+			oldSet.Spec.Template.Spec.Partition = "partition-a"
+			oldSet.Spec.Template.Spec.Project = "project-a"
+			oldSet.Status.ReadyReplicas = 2
+
+			c := newTestControllerWithCapacity(t, scheme, tt.free, deploy, oldSet)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy,
+			}
+
+			err := c.rollingUpdateStrategy(r, []*v2.FirewallSet{oldSet}, oldSet)
+			if tt.wantRecreate {
+				require.Error(t, err)
+				assert.True(t, isRequeue(err), "expected requeue, got: %s", err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			cond := r.Target.Status.Conditions.Get(v2.FirewallDeploymentCapacityAvailable)
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantCondition, cond.Status)
+
+			setList := &v2.FirewallSetList{}
+			require.NoError(t, c.c.GetSeedClient().List(ctx, setList, client.InNamespace("test")))
+
+			var newSets []v2.FirewallSet
+			for _, set := range setList.Items {
+				if set.Name == oldSet.Name {
+					assert.False(t, tt.wantRecreate, "old set should have been deleted")
+					continue
+				}
+				newSets = append(newSets, set)
+			}
+
+			require.Len(t, newSets, 1)
+			assert.Equal(t, "b", newSets[0].Spec.Template.Spec.Image)
+
+			if tt.wantRecreate {
+				// the new set is scaled up after the old set is gone
+				assert.Equal(t, 0, newSets[0].Spec.Replicas)
+				assert.Equal(t, "true", newSets[0].Annotations[v2.RecreateFallbackAnnotation])
+			} else {
+				assert.Equal(t, 2, newSets[0].Spec.Replicas)
+				assert.Equal(t, v2.FirewallRollingUpdateSetDistance, newSets[0].Spec.Distance)
+			}
+		})
+	}
+}
+
+func Test_controller_rollingUpdateStrategy_recreateFallback(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	tests := []struct {
+		name         string
+		oldSetFn     func(set *v2.FirewallSet)
+		wantReplicas int
+		wantRequeue  bool
+	}{
+		{
+			name: "old set is still getting deleted",
+			oldSetFn: func(set *v2.FirewallSet) {
+				set.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+				set.Finalizers = []string{"firewall.metal-stack.io/firewall-controller-manager"}
+			},
+			wantReplicas: 0,
+			wantRequeue:  true,
+		},
+		{
+			name: "old set still has firewalls",
+			oldSetFn: func(set *v2.FirewallSet) {
+				set.Spec.Replicas = 0
+			},
+			wantReplicas: 0,
+			wantRequeue:  true,
+		},
+		{
+			name: "old set is gone",
+			oldSetFn: func(set *v2.FirewallSet) {
+				set.Spec.Replicas = 0
+				set.Status.ReadyReplicas = 0
+			},
+			wantReplicas: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy := &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fwdeploy",
+					Namespace: "test",
+					UID:       "deploy-uid",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Strategy:             v2.StrategyAuto,
+					Replicas:             2,
+					RevisionHistoryLimit: new(1),
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Image:     "b",
+							Size:      "size-a",
+							Partition: "partition-a",
+							Project:   "project-a",
+						},
+					},
+				},
+			}
+
+			oldSet := newHistoryTestSet(deploy, 0, "a", 2)
+			oldSet.Status.ReadyReplicas = 2
+			tt.oldSetFn(oldSet)
+
+			latestSet := newHistoryTestSet(deploy, 1, "b", 0)
+			latestSet.Annotations[v2.RecreateFallbackAnnotation] = "true"
+			latestSet.Spec.Template.Spec = deploy.Spec.Template.Spec
+
+			// there are still not enough free machines for a rolling update
+			c := newTestControllerWithCapacity(t, scheme, 1, deploy, oldSet, latestSet)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy,
+			}
+
+			err := c.rollingUpdateStrategy(r, []*v2.FirewallSet{oldSet, latestSet}, latestSet)
+			if tt.wantRequeue {
+				require.Error(t, err)
+				assert.True(t, isRequeue(err), "expected requeue, got: %s", err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			refetched := &v2.FirewallSet{}
+			require.NoError(t, c.c.GetSeedClient().Get(ctx, client.ObjectKeyFromObject(latestSet), refetched))
+			assert.Equal(t, tt.wantReplicas, refetched.Spec.Replicas)
+		})
+	}
+}
@@ -1,13 +1,18 @@
 package deployment
 
 import (
+	"context"
 	"time"
 
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
 	"github.com/metal-stack/firewall-controller-manager/api/v2/config"
@@ -17,18 +22,22 @@ import (
 )
 
 type controller struct {
-	c               *config.ControllerConfig
-	log             logr.Logger
-	lastSetCreation map[string]time.Time
-	recorder        events.EventRecorder
+	c                *config.ControllerConfig
+	log              logr.Logger
+	lastSetCreation  map[string]time.Time
+	trafficSnapshots map[types.UID]uint64
+	recorder         events.EventRecorder
 }
 
-func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.Manager, c *config.ControllerConfig) error {
-	g := controllers.NewGenericController(log, c.GetSeedClient(), c.GetSeedNamespace(), &controller{
-		c:               c,
-		log:             log,
-		recorder:        recorder,
-		lastSetCreation: map[string]time.Time{},
+func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.Manager, configs *config.ControllerConfigs) error {
+	g := controllers.NewNamespacedGenericController(log, configs, func(c *config.ControllerConfig) controllers.Reconciler[*v2.FirewallDeployment] {
+		return &controller{
+			c:                c,
+			log:              log,
+			recorder:         recorder,
+			lastSetCreation:  map[string]time.Time{},
+			trafficSnapshots: map[types.UID]uint64{},
+		}
 	})
 
 	return ctrl.NewControllerManagedBy(mgr).
@@ -58,12 +67,33 @@ func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.M
 				),
 			),
 		).
-		WithEventFilter(predicate.NewPredicateFuncs(controllers.SkipOtherNamespace(c.GetSeedNamespace()))).
+		// spares are not owned by the deployment, so they are mapped through their label
+		Watches(
+			&v2.Firewall{},
+			handler.EnqueueRequestsFromMapFunc(spareToDeployment),
+		).
+		WithEventFilter(predicate.NewPredicateFuncs(controllers.SkipOtherNamespace(configs.GetSeedNamespaces()...))).
 		Complete(g)
 }
 
-func SetupWebhookWithManager(log logr.Logger, mgr ctrl.Manager, c *config.ControllerConfig) error {
-	defaulter, err := defaults.NewFirewallDeploymentDefaulter(log, c)
+func spareToDeployment(_ context.Context, o client.Object) []reconcile.Request {
+	name, ok := o.GetLabels()[v2.FirewallSpareLabel]
+	if !ok {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{
+			NamespacedName: types.NamespacedName{
+				Namespace: o.GetNamespace(),
+				Name:      name,
+			},
+		},
+	}
+}
+
+func SetupWebhookWithManager(log logr.Logger, mgr ctrl.Manager, configs *config.ControllerConfigs) error {
+	defaulter, err := defaults.NewFirewallDeploymentDefaulter(log, configs)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,89 @@
+package deployment
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/go-openapi/runtime"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/config"
+	"github.com/metal-stack/metal-go/api/client/partition"
+	"github.com/metal-stack/metal-go/api/models"
+	metaltestclient "github.com/metal-stack/metal-go/test/client"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestController(t *testing.T, scheme *k8sruntime.Scheme, objs ...client.Object) *controller {
+	t.Helper()
+
+	return newTestControllerWithCapacity(t, scheme, v2.FirewallMaxReplicas, objs...)
+}
+
+// newTestControllerWithCapacity returns a test controller for which the metal-api reports the given amount of free
+// machines per size and partition.
+func newTestControllerWithCapacity(t *testing.T, scheme *k8sruntime.Scheme, free int32, objs ...client.Object) *controller {
+	t.Helper()
+
+	return newTestControllerWithMetal(t, scheme, free, &metaltestclient.MetalMockFns{}, objs...)
+}
+
+// newTestControllerWithMetal returns a test controller using the given metal mocks, the partition capacity mock
+// reports the given amount of free machines per size and partition.
+func newTestControllerWithMetal(t *testing.T, scheme *k8sruntime.Scheme, free int32, mockFns *metaltestclient.MetalMockFns, objs ...client.Object) *controller {
+	t.Helper()
+
+	seed := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	if mockFns.Partition == nil {
+		mockFns.Partition = func(m *mock.Mock) {
+			m.On("PartitionCapacity", mock.Anything, nil).Return(func(params *partition.PartitionCapacityParams, _ runtime.ClientAuthInfoWriter, _ ...partition.ClientOption) (*partition.PartitionCapacityOK, error) {
+				return &partition.PartitionCapacityOK{
+					Payload: []*models.V1PartitionCapacity{
+						{
+							ID: &params.Body.ID,
+							Servers: []*models.V1ServerCapacity{
+								{
+									Size: &params.Body.Sizeid,
+									Free: free,
+								},
+							},
+						},
+					},
+				}, nil
+			}).Maybe()
+		}
+	}
+
+	_, mc := metaltestclient.NewMetalMockClient(t, mockFns)
+
+	cc, err := config.New(&config.NewControllerConfig{
+		Metal:            mc,
+		SeedClient:       seed,
+		SeedNamespace:    "test",
+		ShootClient:      seed,
+		ShootNamespace:   "firewall",
+		ProgressDeadline: 15 * time.Minute,
+		SkipValidation:   true,
+	})
+	require.NoError(t, err)
+
+	return &controller{
+		c:                cc,
+		log:              testr.New(t),
+		recorder:         events.NewFakeRecorder(100),
+		lastSetCreation:  map[string]time.Time{},
+		trafficSnapshots: map[types.UID]uint64{},
+	}
+}
+
+func isRequeue(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "requeuing after")
+}
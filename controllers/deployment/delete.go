@@ -7,6 +7,7 @@ import (
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
 	"github.com/metal-stack/firewall-controller-manager/controllers"
 	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func (c *controller) Delete(r *controllers.Ctx[*v2.FirewallDeployment]) error {
@@ -17,7 +18,23 @@ func (c *controller) Delete(r *controllers.Ctx[*v2.FirewallDeployment]) error {
 		return fmt.Errorf("unable to get owned sets: %w", err)
 	}
 
-	return c.deleteFirewallSets(r, ownedSets...)
+	err = c.deleteFirewallSets(r, ownedSets...)
+	if err != nil {
+		return err
+	}
+
+	err = c.deleteSpares(r)
+	if err != nil {
+		return err
+	}
+
+	err = c.deleteQuarantinedFirewalls(r)
+	if err != nil {
+		return err
+	}
+
+	// egress ips are released last as the firewalls use them until they are gone
+	return c.releaseAllEgressIPs(r)
 }
 
 func (c *controller) deleteFirewallSets(r *controllers.Ctx[*v2.FirewallDeployment], sets ...*v2.FirewallSet) error {
@@ -43,3 +60,35 @@ func (c *controller) deleteFirewallSets(r *controllers.Ctx[*v2.FirewallDeploymen
 
 	return nil
 }
+
+// deleteQuarantinedFirewalls deletes the quarantined firewalls of the deployment, which are not owned by any firewall set anymore.
+func (c *controller) deleteQuarantinedFirewalls(r *controllers.Ctx[*v2.FirewallDeployment]) error {
+	fwList := &v2.FirewallList{}
+	err := c.c.GetSeedClient().List(r.Ctx, fwList, client.InNamespace(r.Target.Namespace), client.MatchingLabels{
+		v2.FirewallQuarantinedLabel: r.Target.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to list quarantined firewalls: %w", err)
+	}
+
+	quarantined := fwList.GetItems()
+
+	for _, fw := range quarantined {
+		if fw.DeletionTimestamp != nil {
+			continue
+		}
+
+		err := c.c.GetSeedClient().Delete(r.Ctx, fw)
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("unable to delete quarantined firewall: %w", err)
+		}
+
+		c.recorder.Eventf(fw, nil, corev1.EventTypeNormal, "Delete", "deleting firewall", "deleted quarantined firewall %s because the deployment is deleted", fw.Name)
+	}
+
+	if len(quarantined) > 0 {
+		return controllers.RequeueAfter(2*time.Second, "quarantined firewalls are getting deleted, waiting")
+	}
+
+	return nil
+}
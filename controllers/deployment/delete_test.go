@@ -0,0 +1,61 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_controller_deleteQuarantinedFirewalls(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	newQuarantined := func(name, deployment string) *v2.Firewall {
+		return &v2.Firewall{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test",
+				Labels: map[string]string{
+					v2.FirewallQuarantinedLabel: deployment,
+				},
+			},
+		}
+	}
+
+	deploy := &v2.FirewallDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fwdeploy",
+			Namespace: "test",
+			UID:       "deploy-uid",
+		},
+	}
+
+	c := newTestController(t, scheme, deploy, newQuarantined("fw-a", "fwdeploy"), newQuarantined("fw-b", "other"))
+
+	r := &controllers.Ctx[*v2.FirewallDeployment]{
+		Ctx:    ctx,
+		Log:    testr.New(t),
+		Target: deploy,
+	}
+
+	err := c.deleteQuarantinedFirewalls(r)
+	require.Error(t, err)
+	assert.True(t, isRequeue(err))
+
+	fwList := &v2.FirewallList{}
+	require.NoError(t, c.c.GetSeedClient().List(ctx, fwList, client.InNamespace("test")))
+	require.Len(t, fwList.Items, 1)
+	assert.Equal(t, "fw-b", fwList.Items[0].Name)
+
+	require.NoError(t, c.deleteQuarantinedFirewalls(r))
+}
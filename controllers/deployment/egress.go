@@ -0,0 +1,294 @@
+package deployment
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/metal-stack/metal-go/api/client/ip"
+	"github.com/metal-stack/metal-go/api/models"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const egressIPType = "static"
+
+// ensureEgressIPs allocates the static egress ips requested by the egress rules of the template and releases the ones
+// that are not requested anymore. the allocated ips are reported in the status of the deployment.
+//
+// ips that are not requested anymore are only released when the firewalls do not use them anymore, otherwise the ips
+// could be allocated by someone else while the firewalls still send traffic through them.
+func (c *controller) ensureEgressIPs(r *controllers.Ctx[*v2.FirewallDeployment], ownedSets []*v2.FirewallSet, latestSet *v2.FirewallSet) error {
+	if !isEgressIPManagementRequired(r.Target) {
+		return nil
+	}
+
+	allocated, err := c.findEgressIPs(r)
+	if err != nil {
+		return err
+	}
+
+	var (
+		status  []v2.EgressRuleSNAT
+		release []*models.V1IPResponse
+	)
+
+	for networkID, ips := range allocated {
+		if !slices.ContainsFunc(r.Target.Spec.Template.Spec.EgressRules, func(rule v2.EgressRuleSNAT) bool {
+			return rule.NetworkID == networkID && rule.Allocate > 0
+		}) {
+			release = append(release, ips...)
+		}
+	}
+
+	for _, rule := range r.Target.Spec.Template.Spec.EgressRules {
+		if rule.Allocate <= 0 {
+			continue
+		}
+
+		ips := allocated[rule.NetworkID]
+
+		for i := len(ips); i < rule.Allocate; i++ {
+			resp, err := c.c.GetMetal().IP().AllocateIP(ip.NewAllocateIPParams().WithBody(&models.V1IPAllocateRequest{
+				Description: fmt.Sprintf("egress ip of firewall deployment %s/%s", r.Target.Namespace, r.Target.Name),
+				Networkid:   &rule.NetworkID,
+				Projectid:   &r.Target.Spec.Template.Spec.Project,
+				Type:        new(egressIPType),
+				Tags:        egressIPTags(c.c.GetClusterTag(), rule.NetworkID),
+			}).WithContext(r.Ctx), nil)
+			if err != nil {
+				return fmt.Errorf("unable to allocate egress ip in network %q: %w", rule.NetworkID, err)
+			}
+
+			r.Log.Info("allocated egress ip", "ip", pointer.SafeDeref(resp.Payload.Ipaddress), "network", rule.NetworkID)
+			c.recorder.Eventf(r.Target, nil, corev1.EventTypeNormal, "Allocate", "allocating egress ip", "allocated egress ip %s in network %s", pointer.SafeDeref(resp.Payload.Ipaddress), rule.NetworkID)
+
+			ips = append(ips, resp.Payload)
+		}
+
+		if len(ips) > rule.Allocate {
+			release = append(release, ips[rule.Allocate:]...)
+			ips = ips[:rule.Allocate]
+		}
+
+		var addresses []string
+		for _, ip := range ips {
+			addresses = append(addresses, pointer.SafeDeref(ip.Ipaddress))
+		}
+
+		status = append(status, v2.EgressRuleSNAT{
+			NetworkID: rule.NetworkID,
+			IPs:       addresses,
+		})
+	}
+
+	var (
+		releasable []*models.V1IPResponse
+		releasing  []string
+	)
+
+	for _, i := range release {
+		address := pointer.SafeDeref(i.Ipaddress)
+
+		// firewalls stop using the ips with the next sync of the firewall sets, which happens after this
+		if isEgressIPInUse(address, ownedSets, latestSet) {
+			r.Log.Info("egress ip is not requested anymore but still in use, delaying release", "ip", address)
+			releasing = append(releasing, address)
+			continue
+		}
+
+		releasable = append(releasable, i)
+	}
+
+	err = c.releaseEgressIPs(r, releasable...)
+	if err != nil {
+		return err
+	}
+
+	r.Target.Status.EgressIPs = status
+	r.Target.Status.ReleasingEgressIPs = releasing
+
+	return nil
+}
+
+// releaseAllEgressIPs releases all static egress ips that were allocated for the deployment.
+func (c *controller) releaseAllEgressIPs(r *controllers.Ctx[*v2.FirewallDeployment]) error {
+	if !isEgressIPManagementRequired(r.Target) {
+		return nil
+	}
+
+	allocated, err := c.findEgressIPs(r)
+	if err != nil {
+		return err
+	}
+
+	for _, ips := range allocated {
+		err := c.releaseEgressIPs(r, ips...)
+		if err != nil {
+			return err
+		}
+	}
+
+	r.Target.Status.EgressIPs = nil
+	r.Target.Status.ReleasingEgressIPs = nil
+
+	return nil
+}
+
+func (c *controller) releaseEgressIPs(r *controllers.Ctx[*v2.FirewallDeployment], ips ...*models.V1IPResponse) error {
+	for _, i := range ips {
+		address := pointer.SafeDeref(i.Ipaddress)
+
+		_, err := c.c.GetMetal().IP().FreeIP(ip.NewFreeIPParams().WithID(address).WithContext(r.Ctx), nil)
+		if err != nil {
+			return fmt.Errorf("unable to release egress ip %q: %w", address, err)
+		}
+
+		r.Log.Info("released egress ip", "ip", address, "network", pointer.SafeDeref(i.Networkid))
+		c.recorder.Eventf(r.Target, nil, corev1.EventTypeNormal, "Release", "releasing egress ip", "released egress ip %s in network %s", address, pointer.SafeDeref(i.Networkid))
+	}
+
+	return nil
+}
+
+// findEgressIPs returns the static egress ips that were allocated for the deployment grouped by network.
+func (c *controller) findEgressIPs(r *controllers.Ctx[*v2.FirewallDeployment]) (map[string][]*models.V1IPResponse, error) {
+	resp, err := c.c.GetMetal().IP().FindIPs(ip.NewFindIPsParams().WithBody(&models.V1IPFindRequest{
+		Projectid: r.Target.Spec.Template.Spec.Project,
+		Type:      egressIPType,
+		Tags:      []string{c.c.GetClusterTag(), v2.FirewallManagedByTag()},
+	}).WithContext(r.Ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find egress ips: %w", err)
+	}
+
+	result := map[string][]*models.V1IPResponse{}
+
+	for _, i := range resp.Payload {
+		for _, tag := range i.Tags {
+			networkID, ok := strings.CutPrefix(tag, v2.FirewallEgressNetworkAnnotation+"=")
+			if !ok {
+				continue
+			}
+
+			result[networkID] = append(result[networkID], i)
+		}
+	}
+
+	for _, ips := range result {
+		slices.SortFunc(ips, func(a, b *models.V1IPResponse) int {
+			return strings.Compare(pointer.SafeDeref(a.Ipaddress), pointer.SafeDeref(b.Ipaddress))
+		})
+	}
+
+	return result, nil
+}
+
+func egressIPTags(clusterTag, networkID string) []string {
+	return []string{clusterTag, v2.FirewallManagedByTag(), v2.FirewallEgressIPTag(networkID)}
+}
+
+// isEgressIPManagementRequired returns true if egress ips are requested by the template or were allocated before.
+func isEgressIPManagementRequired(d *v2.FirewallDeployment) bool {
+	if len(d.Status.EgressIPs) > 0 || len(d.Status.ReleasingEgressIPs) > 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(d.Spec.Template.Spec.EgressRules, func(rule v2.EgressRuleSNAT) bool {
+		return rule.Allocate > 0
+	})
+}
+
+// isEgressIPInUse returns true if the given egress ip is used by firewall sets that still have firewalls or if the
+// latest set is not ready yet, such that it is unknown whether its firewalls still use the ip.
+func isEgressIPInUse(address string, ownedSets []*v2.FirewallSet, latestSet *v2.FirewallSet) bool {
+	if latestSet == nil {
+		return false
+	}
+
+	if latestSet.Status.ReadyReplicas != latestSet.Spec.Replicas {
+		return true
+	}
+
+	for _, set := range activeSets(ownedSets, latestSet) {
+		for _, rule := range set.Spec.Template.Spec.EgressRules {
+			if slices.Contains(rule.IPs, address) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// firewallTemplate returns the template for the firewalls of the deployment, which contains the allocated egress ips.
+func firewallTemplate(d *v2.FirewallDeployment) v2.FirewallTemplateSpec {
+	template := d.Spec.Template.DeepCopy()
+
+	for i, rule := range template.Spec.EgressRules {
+		for _, allocated := range d.Status.EgressIPs {
+			if allocated.NetworkID != rule.NetworkID {
+				continue
+			}
+
+			for _, ip := range allocated.IPs {
+				if !slices.Contains(rule.IPs, ip) {
+					rule.IPs = append(rule.IPs, ip)
+				}
+			}
+		}
+
+		template.Spec.EgressRules[i] = rule
+	}
+
+	return *template
+}
+
+// addedEgressIPs returns the allocated egress ips that are added to the egress rules of the template by firewallTemplate.
+func addedEgressIPs(d *v2.FirewallDeployment) []string {
+	var result []string
+
+	for _, rule := range d.Spec.Template.Spec.EgressRules {
+		for _, allocated := range d.Status.EgressIPs {
+			if allocated.NetworkID != rule.NetworkID {
+				continue
+			}
+
+			for _, ip := range allocated.IPs {
+				if !slices.Contains(rule.IPs, ip) && !slices.Contains(result, ip) {
+					result = append(result, ip)
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// userTemplate returns the template of the given set without the egress ips that were added by firewallTemplate, which
+// is the template as it was specified in the deployment.
+func userTemplate(set *v2.FirewallSet) v2.FirewallTemplateSpec {
+	template := set.Spec.Template.DeepCopy()
+
+	value, ok := set.Annotations[v2.AllocatedEgressIPsAnnotation]
+	if !ok {
+		return *template
+	}
+
+	added := strings.Split(value, ",")
+
+	for i, rule := range template.Spec.EgressRules {
+		rule.IPs = slices.DeleteFunc(rule.IPs, func(ip string) bool {
+			return slices.Contains(added, ip)
+		})
+		if len(rule.IPs) == 0 {
+			rule.IPs = nil
+		}
+
+		template.Spec.EgressRules[i] = rule
+	}
+
+	return *template
+}
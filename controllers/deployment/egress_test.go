@@ -0,0 +1,272 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/go-openapi/runtime"
+	"github.com/google/go-cmp/cmp"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/metal-stack/metal-go/api/client/ip"
+	"github.com/metal-stack/metal-go/api/models"
+	metaltestclient "github.com/metal-stack/metal-go/test/client"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+func Test_controller_ensureEgressIPs(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := k8sruntime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	existingIP := func(address, networkID string) *models.V1IPResponse {
+		return &models.V1IPResponse{
+			Ipaddress: new(address),
+			Networkid: new(networkID),
+			Tags:      egressIPTags("", networkID),
+		}
+	}
+
+	newSet := func(ready int, ips ...string) *v2.FirewallSet {
+		return &v2.FirewallSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "set",
+				UID:  "set-uid",
+			},
+			Spec: v2.FirewallSetSpec{
+				Replicas: 1,
+				Template: v2.FirewallTemplateSpec{
+					Spec: v2.FirewallSpec{
+						EgressRules: []v2.EgressRuleSNAT{{NetworkID: "internet", IPs: ips, Allocate: 1}},
+					},
+				},
+			},
+			Status: v2.FirewallSetStatus{
+				ReadyReplicas: ready,
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		rules         []v2.EgressRuleSNAT
+		status        []v2.EgressRuleSNAT
+		existing      []*models.V1IPResponse
+		latestSet     *v2.FirewallSet
+		wantAllocs    int
+		wantFreed     []string
+		wantStatus    []v2.EgressRuleSNAT
+		wantReleasing []string
+		wantNoCalls   bool
+	}{
+		{
+			name:        "no egress ips requested",
+			rules:       []v2.EgressRuleSNAT{{NetworkID: "internet", IPs: []string{"1.1.1.1"}}},
+			wantNoCalls: true,
+		},
+		{
+			name:       "allocates missing egress ips",
+			rules:      []v2.EgressRuleSNAT{{NetworkID: "internet", Allocate: 2}},
+			existing:   []*models.V1IPResponse{existingIP("1.1.1.1", "internet")},
+			wantAllocs: 1,
+			wantStatus: []v2.EgressRuleSNAT{{NetworkID: "internet", IPs: []string{"1.1.1.1", "2.2.2.2"}}},
+		},
+		{
+			name:       "releases excess egress ips",
+			rules:      []v2.EgressRuleSNAT{{NetworkID: "internet", Allocate: 1}},
+			existing:   []*models.V1IPResponse{existingIP("1.1.1.2", "internet"), existingIP("1.1.1.1", "internet")},
+			wantFreed:  []string{"1.1.1.2"},
+			wantStatus: []v2.EgressRuleSNAT{{NetworkID: "internet", IPs: []string{"1.1.1.1"}}},
+		},
+		{
+			name:          "delays release while the latest set uses the excess egress ips",
+			rules:         []v2.EgressRuleSNAT{{NetworkID: "internet", Allocate: 1}},
+			existing:      []*models.V1IPResponse{existingIP("1.1.1.2", "internet"), existingIP("1.1.1.1", "internet")},
+			latestSet:     newSet(1, "1.1.1.1", "1.1.1.2"),
+			wantStatus:    []v2.EgressRuleSNAT{{NetworkID: "internet", IPs: []string{"1.1.1.1"}}},
+			wantReleasing: []string{"1.1.1.2"},
+		},
+		{
+			name:          "delays release while the latest set is not ready",
+			rules:         []v2.EgressRuleSNAT{{NetworkID: "internet", Allocate: 1}},
+			status:        []v2.EgressRuleSNAT{{NetworkID: "internet", IPs: []string{"1.1.1.1"}}},
+			existing:      []*models.V1IPResponse{existingIP("1.1.1.2", "internet"), existingIP("1.1.1.1", "internet")},
+			latestSet:     newSet(0, "1.1.1.1"),
+			wantStatus:    []v2.EgressRuleSNAT{{NetworkID: "internet", IPs: []string{"1.1.1.1"}}},
+			wantReleasing: []string{"1.1.1.2"},
+		},
+		{
+			name:       "releases excess egress ips when the latest set does not use them anymore",
+			rules:      []v2.EgressRuleSNAT{{NetworkID: "internet", Allocate: 1}},
+			existing:   []*models.V1IPResponse{existingIP("1.1.1.2", "internet"), existingIP("1.1.1.1", "internet")},
+			latestSet:  newSet(1, "1.1.1.1"),
+			wantFreed:  []string{"1.1.1.2"},
+			wantStatus: []v2.EgressRuleSNAT{{NetworkID: "internet", IPs: []string{"1.1.1.1"}}},
+		},
+		{
+			name:      "releases egress ips of removed rules",
+			rules:     nil,
+			status:    []v2.EgressRuleSNAT{{NetworkID: "internet", IPs: []string{"1.1.1.1"}}},
+			existing:  []*models.V1IPResponse{existingIP("1.1.1.1", "internet")},
+			wantFreed: []string{"1.1.1.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				allocs int
+				freed  []string
+			)
+
+			deploy := &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fwdeploy",
+					Namespace: "test",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Project:     "project-a",
+							EgressRules: tt.rules,
+						},
+					},
+				},
+				Status: v2.FirewallDeploymentStatus{
+					EgressIPs: tt.status,
+				},
+			}
+
+			c := newTestControllerWithMetal(t, scheme, v2.FirewallMaxReplicas, &metaltestclient.MetalMockFns{
+				IP: func(m *mock.Mock) {
+					m.On("FindIPs", mock.Anything, nil).Return(&ip.FindIPsOK{Payload: tt.existing}, nil).Maybe()
+					m.On("AllocateIP", mock.Anything, nil).Return(func(params *ip.AllocateIPParams, _ runtime.ClientAuthInfoWriter, _ ...ip.ClientOption) (*ip.AllocateIPCreated, error) {
+						allocs++
+						return &ip.AllocateIPCreated{Payload: &models.V1IPResponse{
+							Ipaddress: new("2.2.2.2"),
+							Networkid: params.Body.Networkid,
+							Tags:      params.Body.Tags,
+						}}, nil
+					}).Maybe()
+					m.On("FreeIP", mock.Anything, nil).Return(func(params *ip.FreeIPParams, _ runtime.ClientAuthInfoWriter, _ ...ip.ClientOption) (*ip.FreeIPOK, error) {
+						freed = append(freed, params.ID)
+						return &ip.FreeIPOK{}, nil
+					}).Maybe()
+				},
+			}, deploy)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy,
+			}
+
+			var ownedSets []*v2.FirewallSet
+			if tt.latestSet != nil {
+				ownedSets = append(ownedSets, tt.latestSet)
+			}
+
+			require.NoError(t, c.ensureEgressIPs(r, ownedSets, tt.latestSet))
+
+			if diff := cmp.Diff(tt.wantAllocs, allocs); diff != "" {
+				t.Errorf("allocations diff = %s", diff)
+			}
+			if diff := cmp.Diff(tt.wantFreed, freed); diff != "" {
+				t.Errorf("released ips diff = %s", diff)
+			}
+			if tt.wantNoCalls {
+				return
+			}
+			if diff := cmp.Diff(tt.wantStatus, r.Target.Status.EgressIPs); diff != "" {
+				t.Errorf("status diff = %s", diff)
+			}
+			if diff := cmp.Diff(tt.wantReleasing, r.Target.Status.ReleasingEgressIPs); diff != "" {
+				t.Errorf("releasing ips diff = %s", diff)
+			}
+		})
+	}
+}
+
+func Test_firewallTemplate(t *testing.T) {
+	deploy := &v2.FirewallDeployment{
+		Spec: v2.FirewallDeploymentSpec{
+			Template: v2.FirewallTemplateSpec{
+				Spec: v2.FirewallSpec{
+					EgressRules: []v2.EgressRuleSNAT{
+						{NetworkID: "internet", IPs: []string{"1.1.1.1"}, Allocate: 2},
+						{NetworkID: "mpls", IPs: []string{"3.3.3.3"}},
+					},
+				},
+			},
+		},
+		Status: v2.FirewallDeploymentStatus{
+			EgressIPs: []v2.EgressRuleSNAT{{NetworkID: "internet", IPs: []string{"1.1.1.1", "2.2.2.2"}}},
+		},
+	}
+
+	want := []v2.EgressRuleSNAT{
+		{NetworkID: "internet", IPs: []string{"1.1.1.1", "2.2.2.2"}, Allocate: 2},
+		{NetworkID: "mpls", IPs: []string{"3.3.3.3"}},
+	}
+
+	if diff := cmp.Diff(want, firewallTemplate(deploy).Spec.EgressRules); diff != "" {
+		t.Errorf("firewallTemplate() diff = %s", diff)
+	}
+
+	// the template of the deployment must not be altered
+	if diff := cmp.Diff([]string{"1.1.1.1"}, deploy.Spec.Template.Spec.EgressRules[0].IPs); diff != "" {
+		t.Errorf("deployment template diff = %s", diff)
+	}
+}
+
+func Test_userTemplate(t *testing.T) {
+	deploy := &v2.FirewallDeployment{
+		Spec: v2.FirewallDeploymentSpec{
+			Template: v2.FirewallTemplateSpec{
+				Spec: v2.FirewallSpec{
+					EgressRules: []v2.EgressRuleSNAT{
+						{NetworkID: "internet", IPs: []string{"1.1.1.1"}, Allocate: 2},
+						{NetworkID: "dmz", Allocate: 1},
+						{NetworkID: "mpls", IPs: []string{"3.3.3.3"}},
+					},
+				},
+			},
+		},
+		Status: v2.FirewallDeploymentStatus{
+			EgressIPs: []v2.EgressRuleSNAT{
+				{NetworkID: "internet", IPs: []string{"1.1.1.1", "2.2.2.2"}},
+				{NetworkID: "dmz", IPs: []string{"4.4.4.4"}},
+			},
+		},
+	}
+
+	if diff := cmp.Diff([]string{"2.2.2.2", "4.4.4.4"}, addedEgressIPs(deploy)); diff != "" {
+		t.Errorf("addedEgressIPs() diff = %s", diff)
+	}
+
+	set := &v2.FirewallSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				v2.AllocatedEgressIPsAnnotation: "2.2.2.2,4.4.4.4",
+			},
+		},
+		Spec: v2.FirewallSetSpec{
+			Template: firewallTemplate(deploy),
+		},
+	}
+
+	if diff := cmp.Diff(deploy.Spec.Template, userTemplate(set)); diff != "" {
+		t.Errorf("userTemplate() diff = %s", diff)
+	}
+
+	// sets without the annotation are restored as they are
+	delete(set.Annotations, v2.AllocatedEgressIPsAnnotation)
+
+	if diff := cmp.Diff(set.Spec.Template, userTemplate(set)); diff != "" {
+		t.Errorf("userTemplate() diff = %s", diff)
+	}
+}
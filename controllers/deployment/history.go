@@ -0,0 +1,294 @@
+package deployment
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// retireOldSets removes all sets except the latest set. if a revision history limit is configured, the newest old sets
+// are kept as revision history scaled down to zero replicas. sets exceeding the limit are deleted.
+func (c *controller) retireOldSets(r *controllers.Ctx[*v2.FirewallDeployment], ownedSets []*v2.FirewallSet, latestSet *v2.FirewallSet) error {
+	oldSets := controllers.Except(ownedSets, latestSet)
+
+	limit := revisionHistoryLimit(r.Target)
+	if limit == 0 {
+		return c.deleteFirewallSets(r, oldSets...)
+	}
+
+	sorted, err := sortByRevisionDescending(oldSets)
+	if err != nil {
+		return err
+	}
+
+	var (
+		toDelete []*v2.FirewallSet
+		waiting  bool
+	)
+
+	for i, set := range sorted {
+		if i >= limit {
+			toDelete = append(toDelete, set)
+			continue
+		}
+
+		if set.Spec.Replicas > 0 {
+			err := c.scaleFirewallSet(r, set, 0)
+			if err != nil {
+				return err
+			}
+
+			waiting = true
+		}
+
+		if set.Status.ReadyReplicas+set.Status.ProgressingReplicas+set.Status.UnhealthyReplicas > 0 {
+			waiting = true
+		}
+	}
+
+	if len(toDelete) > 0 {
+		r.Log.Info("pruning firewall sets exceeding the revision history limit", "limit", limit)
+
+		return c.deleteFirewallSets(r, toDelete...)
+	}
+
+	if waiting {
+		return controllers.RequeueAfter(2*time.Second, "old firewall sets are getting scaled down, waiting")
+	}
+
+	return nil
+}
+
+func (c *controller) scaleFirewallSet(r *controllers.Ctx[*v2.FirewallDeployment], set *v2.FirewallSet, replicas int) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		refetched := &v2.FirewallSet{}
+		err := c.c.GetSeedClient().Get(r.Ctx, client.ObjectKeyFromObject(set), refetched)
+		if err != nil {
+			return fmt.Errorf("unable re-fetch firewall set: %w", err)
+		}
+
+		refetched.Spec.Replicas = replicas
+
+		err = c.c.GetSeedClient().Update(r.Ctx, refetched)
+		if err != nil {
+			return fmt.Errorf("unable to scale firewall set: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	r.Log.Info("scaled firewall set", "set-name", set.Name, "replicas", replicas)
+
+	c.recorder.Eventf(set, nil, corev1.EventTypeNormal, "Scale", "scaling set", "scaled firewall set %s to %d replicas", set.Name, replicas)
+
+	return nil
+}
+
+// rollback handles the rollback annotation by restoring the template of the requested revision in the deployment spec.
+// a new firewall set is enforced through the roll set annotation on the latest set, which is removed again once the new set exists.
+func (c *controller) rollback(r *controllers.Ctx[*v2.FirewallDeployment], ownedSets []*v2.FirewallSet, latestSet *v2.FirewallSet) error {
+	value := r.Target.Annotations[v2.RollbackToAnnotation]
+
+	revision, err := strconv.Atoi(value)
+	if err != nil {
+		r.Log.Error(err, "ignoring invalid rollback annotation", "value", value)
+		c.recorder.Eventf(r.Target, nil, corev1.EventTypeWarning, "RollbackInvalid", "rolling back", "invalid revision for rollback: %q", value)
+
+		return v2.RemoveAnnotation(r.Ctx, c.c.GetSeedClient(), r.Target, v2.RollbackToAnnotation)
+	}
+
+	var target *v2.FirewallSet
+	for _, set := range ownedSets {
+		setRevision, err := controllers.Revision(set)
+		if err != nil {
+			return err
+		}
+
+		if setRevision == revision {
+			target = set
+			break
+		}
+	}
+
+	if target == nil {
+		r.Log.Info("revision for rollback not found in revision history", "revision", revision)
+		c.recorder.Eventf(r.Target, nil, corev1.EventTypeWarning, "RollbackRevisionNotFound", "rolling back", "unable to find revision %d in revision history", revision)
+
+		return v2.RemoveAnnotation(r.Ctx, c.c.GetSeedClient(), r.Target, v2.RollbackToAnnotation)
+	}
+
+	if target.UID == latestSet.UID {
+		r.Log.Info("revision for rollback is already the latest revision", "revision", revision)
+
+		return v2.RemoveAnnotation(r.Ctx, c.c.GetSeedClient(), r.Target, v2.RollbackToAnnotation)
+	}
+
+	r.Log.Info("rolling back firewall deployment", "revision", revision, "set-name", target.Name)
+
+	delete(r.Target.Annotations, v2.RollbackToAnnotation)
+
+	err = c.restoreTemplate(r, target)
+	if err != nil {
+		return err
+	}
+
+	// the template of the revision may not contain significant changes compared to the latest set, so a new set is enforced
+	err = v2.AddAnnotation(r.Ctx, c.c.GetSeedClient(), latestSet, v2.RollSetAnnotation, strconv.FormatBool(true))
+	if err != nil {
+		return fmt.Errorf("unable to annotate latest firewall set for roll: %w", err)
+	}
+
+	c.recorder.Eventf(r.Target, nil, corev1.EventTypeNormal, "Rollback", "rolling back", "rolled back to template of revision %d", revision)
+
+	return nil
+}
+
+// autoRollback returns to the previous firewall set by deleting the latest set. the template of the deployment is
+// restored from the previous set as otherwise the controller would immediately create a new set again.
+func (c *controller) autoRollback(r *controllers.Ctx[*v2.FirewallDeployment], ownedSets []*v2.FirewallSet, latestSet *v2.FirewallSet) error {
+	previousSet, err := controllers.MaxRevisionOf(controllers.Except(activeSets(ownedSets, latestSet), latestSet))
+	if err != nil {
+		return err
+	}
+
+	if previousSet == nil {
+		r.Log.Info("no previous firewall set present, cannot roll back")
+		return nil
+	}
+
+	r.Log.Info("progress deadline exceeded, rolling back to previous firewall set", "set-name", previousSet.Name)
+
+	err = c.restoreTemplate(r, previousSet)
+	if err != nil {
+		return err
+	}
+
+	if previousSet.Spec.Distance != v2.FirewallShortestDistance {
+		previousSet.Spec.Distance = v2.FirewallShortestDistance
+
+		err = c.c.GetSeedClient().Update(r.Ctx, previousSet)
+		if err != nil {
+			return fmt.Errorf("unable to swap previous set distance to %d: %w", v2.FirewallShortestDistance, err)
+		}
+	}
+
+	// the roll set annotation would otherwise roll the previous set again as soon as it has become the latest set
+	err = v2.RemoveAnnotation(r.Ctx, c.c.GetSeedClient(), previousSet, v2.RollSetAnnotation)
+	if err != nil {
+		return fmt.Errorf("unable to remove roll set annotation from previous firewall set: %w", err)
+	}
+
+	cond := v2.NewCondition(v2.FirewallDeploymentRolledBack, v2.ConditionTrue, "ProgressDeadlineExceeded", fmt.Sprintf("FirewallSet %q has timed out progressing, rolled back to FirewallSet %q.", latestSet.Name, previousSet.Name))
+	r.Target.Status.Conditions.Set(cond)
+
+	c.recorder.Eventf(r.Target, nil, corev1.EventTypeWarning, "RolledBack", "rolling back", "firewall set %s has timed out progressing, rolled back to firewall set %s", latestSet.Name, previousSet.Name)
+
+	return c.deleteFirewallSets(r, latestSet)
+}
+
+// removeStaleRollSetAnnotations removes the roll set annotation from the given sets. the annotation is only considered on the
+// latest set, once a newer set exists it has been consumed and must not remain on sets kept in the revision history as
+// otherwise a set roll would be triggered again when such a set becomes the latest set.
+func (c *controller) removeStaleRollSetAnnotations(r *controllers.Ctx[*v2.FirewallDeployment], sets []*v2.FirewallSet) error {
+	for _, set := range sets {
+		if !v2.IsAnnotationPresent(set, v2.RollSetAnnotation) {
+			continue
+		}
+
+		err := v2.RemoveAnnotation(r.Ctx, c.c.GetSeedClient(), set, v2.RollSetAnnotation)
+		if err != nil {
+			return fmt.Errorf("unable to remove roll set annotation from firewall set %q: %w", set.Name, err)
+		}
+
+		r.Log.Info("removed roll set annotation from old firewall set", "set-name", set.Name)
+	}
+
+	return nil
+}
+
+// setRolloutCompleted marks the rollout of the latest set as completed. if the deployment was rolled back automatically before,
+// the rolled back condition is reset as soon as a set that was created after the rollback has progressed successfully.
+func setRolloutCompleted(r *controllers.Ctx[*v2.FirewallDeployment], latestSet *v2.FirewallSet) {
+	cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionTrue, "NewFirewallSetAvailable", fmt.Sprintf("FirewallSet %q has successfully progressed.", latestSet.Name))
+	r.Target.Status.Conditions.Set(cond)
+
+	rolledBack := r.Target.Status.Conditions.Get(v2.FirewallDeploymentRolledBack)
+	if rolledBack == nil || rolledBack.Status != v2.ConditionTrue {
+		return
+	}
+
+	// the set that was rolled back to already existed when the rollback happened
+	if latestSet.CreationTimestamp.Time.Before(rolledBack.LastTransitionTime.Time) {
+		return
+	}
+
+	cond = v2.NewCondition(v2.FirewallDeploymentRolledBack, v2.ConditionFalse, "NewFirewallSetAvailable", fmt.Sprintf("FirewallSet %q has successfully progressed after the rollback.", latestSet.Name))
+	r.Target.Status.Conditions.Set(cond)
+}
+
+func (c *controller) restoreTemplate(r *controllers.Ctx[*v2.FirewallDeployment], set *v2.FirewallSet) error {
+	status := r.Target.Status.DeepCopy()
+
+	// the allocated egress ips must not end up in the spec of the deployment as they would never be released then
+	r.Target.Spec.Template = userTemplate(set)
+
+	err := c.c.GetSeedClient().Update(r.Ctx, r.Target)
+	if err != nil {
+		return fmt.Errorf("unable to restore template of firewall set %q: %w", set.Name, err)
+	}
+
+	// the update response contains the status of the api server, but we want to keep the status of this reconciliation
+	r.Target.Status = *status
+
+	return nil
+}
+
+// activeSets returns the latest set and all other sets with replicas, i.e. sets that are not part of the revision history.
+func activeSets(sets []*v2.FirewallSet, latestSet *v2.FirewallSet) []*v2.FirewallSet {
+	var result []*v2.FirewallSet
+
+	for _, set := range sets {
+		if set.UID == latestSet.UID || set.Spec.Replicas > 0 {
+			result = append(result, set)
+		}
+	}
+
+	return result
+}
+
+func revisionHistoryLimit(d *v2.FirewallDeployment) int {
+	if d.Spec.RevisionHistoryLimit == nil {
+		return 0
+	}
+
+	return *d.Spec.RevisionHistoryLimit
+}
+
+func sortByRevisionDescending(sets []*v2.FirewallSet) ([]*v2.FirewallSet, error) {
+	revisions := map[*v2.FirewallSet]int{}
+	for _, set := range sets {
+		revision, err := controllers.Revision(set)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse revision for firewall set: %w", err)
+		}
+
+		revisions[set] = revision
+	}
+
+	result := slices.Clone(sets)
+	slices.SortStableFunc(result, func(a, b *v2.FirewallSet) int {
+		return revisions[b] - revisions[a]
+	})
+
+	return result, nil
+}
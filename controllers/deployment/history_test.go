@@ -0,0 +1,440 @@
+package deployment
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newHistoryTestSet(deploy *v2.FirewallDeployment, revision int, image string, replicas int) *v2.FirewallSet {
+	name := "set-" + strconv.Itoa(revision)
+
+	return &v2.FirewallSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: deploy.Namespace,
+			UID:       types.UID(name + "-uid"),
+			Annotations: map[string]string{
+				v2.RevisionAnnotation: strconv.Itoa(revision),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(deploy, v2.GroupVersion.WithKind("FirewallDeployment")),
+			},
+		},
+		Spec: v2.FirewallSetSpec{
+			Replicas: replicas,
+			Template: v2.FirewallTemplateSpec{
+				Spec: v2.FirewallSpec{
+					Image: image,
+				},
+			},
+		},
+	}
+}
+
+func Test_controller_retireOldSets(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	deploy := &v2.FirewallDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fwdeploy",
+			Namespace: "test",
+			UID:       "deploy-uid",
+		},
+	}
+
+	tests := []struct {
+		name         string
+		historyLimit *int
+		sets         []*v2.FirewallSet
+		wantRequeue  bool
+		wantSets     map[string]int
+	}{
+		{
+			name:         "without revision history old sets are deleted",
+			historyLimit: nil,
+			sets: []*v2.FirewallSet{
+				newHistoryTestSet(deploy, 0, "a", 2),
+				newHistoryTestSet(deploy, 1, "b", 2),
+			},
+			wantRequeue: true,
+			wantSets: map[string]int{
+				"set-1": 2,
+			},
+		},
+		{
+			name:         "old set is scaled down and kept in revision history",
+			historyLimit: new(2),
+			sets: []*v2.FirewallSet{
+				newHistoryTestSet(deploy, 0, "a", 2),
+				newHistoryTestSet(deploy, 1, "b", 2),
+			},
+			wantRequeue: true,
+			wantSets: map[string]int{
+				"set-0": 0,
+				"set-1": 2,
+			},
+		},
+		{
+			name:         "sets exceeding the revision history limit are pruned",
+			historyLimit: new(1),
+			sets: []*v2.FirewallSet{
+				newHistoryTestSet(deploy, 0, "a", 0),
+				newHistoryTestSet(deploy, 1, "b", 0),
+				newHistoryTestSet(deploy, 2, "c", 2),
+			},
+			wantRequeue: true,
+			wantSets: map[string]int{
+				"set-1": 0,
+				"set-2": 2,
+			},
+		},
+		{
+			name:         "nothing to do when revision history is settled",
+			historyLimit: new(2),
+			sets: []*v2.FirewallSet{
+				newHistoryTestSet(deploy, 0, "a", 0),
+				newHistoryTestSet(deploy, 1, "b", 0),
+				newHistoryTestSet(deploy, 2, "c", 2),
+			},
+			wantRequeue: false,
+			wantSets: map[string]int{
+				"set-0": 0,
+				"set-1": 0,
+				"set-2": 2,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objs []client.Object
+			for _, set := range tt.sets {
+				objs = append(objs, set)
+			}
+
+			c := newTestController(t, scheme, objs...)
+
+			target := deploy.DeepCopy()
+			target.Spec.RevisionHistoryLimit = tt.historyLimit
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: target,
+			}
+
+			latestSet, err := controllers.MaxRevisionOf(tt.sets)
+			require.NoError(t, err)
+
+			err = c.retireOldSets(r, tt.sets, latestSet)
+			if tt.wantRequeue {
+				require.Error(t, err)
+				assert.True(t, isRequeue(err), "expected requeue, got: %s", err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			sets := &v2.FirewallSetList{}
+			require.NoError(t, c.c.GetSeedClient().List(ctx, sets, client.InNamespace("test")))
+
+			got := map[string]int{}
+			for _, set := range sets.Items {
+				got[set.Name] = set.Spec.Replicas
+			}
+
+			assert.Equal(t, tt.wantSets, got)
+		})
+	}
+}
+
+func Test_controller_rollback(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	newDeployment := func(rollbackTo string) *v2.FirewallDeployment {
+		return &v2.FirewallDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fwdeploy",
+				Namespace: "test",
+				UID:       "deploy-uid",
+				Annotations: map[string]string{
+					v2.RollbackToAnnotation: rollbackTo,
+				},
+			},
+			Spec: v2.FirewallDeploymentSpec{
+				Template: v2.FirewallTemplateSpec{
+					Spec: v2.FirewallSpec{
+						Image: "c",
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		rollbackTo   string
+		wantImage    string
+		wantRollFlag bool
+	}{
+		{
+			name:         "rollback to revision in history",
+			rollbackTo:   "0",
+			wantImage:    "a",
+			wantRollFlag: true,
+		},
+		{
+			name:         "rollback to unknown revision is ignored",
+			rollbackTo:   "5",
+			wantImage:    "c",
+			wantRollFlag: false,
+		},
+		{
+			name:         "rollback to invalid revision is ignored",
+			rollbackTo:   "foo",
+			wantImage:    "c",
+			wantRollFlag: false,
+		},
+		{
+			name:         "rollback to latest revision does nothing",
+			rollbackTo:   "2",
+			wantImage:    "c",
+			wantRollFlag: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy := newDeployment(tt.rollbackTo)
+
+			sets := []*v2.FirewallSet{
+				newHistoryTestSet(deploy, 0, "a", 0),
+				newHistoryTestSet(deploy, 1, "b", 0),
+				newHistoryTestSet(deploy, 2, "c", 2),
+			}
+
+			objs := []client.Object{deploy}
+			for _, set := range sets {
+				objs = append(objs, set)
+			}
+
+			c := newTestController(t, scheme, objs...)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy,
+			}
+
+			err := c.rollback(r, sets, sets[2])
+			require.NoError(t, err)
+
+			refetched := &v2.FirewallDeployment{}
+			require.NoError(t, c.c.GetSeedClient().Get(ctx, client.ObjectKeyFromObject(deploy), refetched))
+			assert.False(t, v2.IsAnnotationPresent(refetched, v2.RollbackToAnnotation))
+			assert.Equal(t, tt.wantImage, refetched.Spec.Template.Spec.Image)
+
+			latestSet := &v2.FirewallSet{}
+			require.NoError(t, c.c.GetSeedClient().Get(ctx, client.ObjectKeyFromObject(sets[2]), latestSet))
+			assert.Equal(t, tt.wantRollFlag, v2.IsAnnotationTrue(latestSet, v2.RollSetAnnotation))
+		})
+	}
+}
+
+func Test_controller_removeStaleRollSetAnnotations(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	deploy := &v2.FirewallDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fwdeploy",
+			Namespace: "test",
+			UID:       "deploy-uid",
+		},
+	}
+
+	var (
+		historySet = newHistoryTestSet(deploy, 0, "a", 0)
+		oldSet     = newHistoryTestSet(deploy, 1, "b", 1)
+		latestSet  = newHistoryTestSet(deploy, 2, "b", 1)
+	)
+
+	oldSet.Annotations[v2.RollSetAnnotation] = "true"
+	latestSet.Annotations[v2.RollSetAnnotation] = "true"
+
+	c := newTestController(t, scheme, historySet, oldSet, latestSet)
+
+	r := &controllers.Ctx[*v2.FirewallDeployment]{
+		Ctx:    ctx,
+		Log:    testr.New(t),
+		Target: deploy,
+	}
+
+	err := c.removeStaleRollSetAnnotations(r, []*v2.FirewallSet{historySet, oldSet})
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		set  *v2.FirewallSet
+		want bool
+	}{
+		{set: historySet, want: false},
+		{set: oldSet, want: false},
+		{set: latestSet, want: true},
+	} {
+		refetched := &v2.FirewallSet{}
+		require.NoError(t, c.c.GetSeedClient().Get(ctx, client.ObjectKeyFromObject(tt.set), refetched))
+		assert.Equal(t, tt.want, v2.IsAnnotationTrue(refetched, v2.RollSetAnnotation), tt.set.Name)
+	}
+}
+
+func Test_controller_autoRollback(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	deploy := &v2.FirewallDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fwdeploy",
+			Namespace: "test",
+			UID:       "deploy-uid",
+		},
+		Spec: v2.FirewallDeploymentSpec{
+			AutoRollback: true,
+			Template: v2.FirewallTemplateSpec{
+				Spec: v2.FirewallSpec{
+					Image: "b",
+				},
+			},
+		},
+	}
+
+	// the roll of the previous set was initiated through the roll set annotation
+	previousSet := newHistoryTestSet(deploy, 0, "a", 1)
+	previousSet.Annotations[v2.RollSetAnnotation] = "true"
+
+	latestSet := newHistoryTestSet(deploy, 1, "b", 1)
+	latestSet.Spec.Distance = v2.FirewallRollingUpdateSetDistance
+
+	c := newTestController(t, scheme, deploy, previousSet, latestSet)
+
+	r := &controllers.Ctx[*v2.FirewallDeployment]{
+		Ctx:    ctx,
+		Log:    testr.New(t),
+		Target: deploy,
+	}
+
+	sets := []*v2.FirewallSet{previousSet, latestSet}
+
+	err := c.autoRollback(r, sets, latestSet)
+	require.Error(t, err)
+	assert.True(t, isRequeue(err), "expected requeue, got: %s", err)
+
+	setList := &v2.FirewallSetList{}
+	require.NoError(t, c.c.GetSeedClient().List(ctx, setList, client.InNamespace("test")))
+	require.Len(t, setList.Items, 1)
+
+	remaining := &setList.Items[0]
+	assert.Equal(t, previousSet.Name, remaining.Name)
+	assert.False(t, v2.IsAnnotationPresent(remaining, v2.RollSetAnnotation))
+	assert.Equal(t, v2.FirewallShortestDistance, remaining.Spec.Distance)
+
+	// the previous set is the latest set now and must not be rolled again
+	r.Target.Spec.Template.Spec.Image = "a"
+	assert.False(t, c.isNewSetRequired(r, remaining))
+
+	cond := r.Target.Status.Conditions.Get(v2.FirewallDeploymentRolledBack)
+	require.NotNil(t, cond)
+	assert.Equal(t, v2.ConditionTrue, cond.Status)
+}
+
+func Test_setRolloutCompleted(t *testing.T) {
+	var (
+		rolledBackAt = time.Now().Add(-10 * time.Minute)
+		deploy       = &v2.FirewallDeployment{}
+	)
+
+	tests := []struct {
+		name           string
+		rolledBack     *v2.Condition
+		setCreated     time.Time
+		wantRolledBack *v2.ConditionStatus
+	}{
+		{
+			name:           "no rollback happened",
+			setCreated:     time.Now(),
+			wantRolledBack: nil,
+		},
+		{
+			name: "rolled back to the latest set",
+			rolledBack: &v2.Condition{
+				Type:               v2.FirewallDeploymentRolledBack,
+				Status:             v2.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(rolledBackAt),
+				Reason:             "ProgressDeadlineExceeded",
+			},
+			setCreated:     rolledBackAt.Add(-1 * time.Hour),
+			wantRolledBack: new(v2.ConditionTrue),
+		},
+		{
+			name: "new set progressed after rollback",
+			rolledBack: &v2.Condition{
+				Type:               v2.FirewallDeploymentRolledBack,
+				Status:             v2.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(rolledBackAt),
+				Reason:             "ProgressDeadlineExceeded",
+			},
+			setCreated:     rolledBackAt.Add(5 * time.Minute),
+			wantRolledBack: new(v2.ConditionFalse),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := deploy.DeepCopy()
+			if tt.rolledBack != nil {
+				target.Status.Conditions = v2.Conditions{*tt.rolledBack}
+			}
+
+			set := newHistoryTestSet(target, 1, "b", 1)
+			set.CreationTimestamp = metav1.NewTime(tt.setCreated)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    context.Background(),
+				Log:    testr.New(t),
+				Target: target,
+			}
+
+			setRolloutCompleted(r, set)
+
+			progressing := r.Target.Status.Conditions.Get(v2.FirewallDeploymentProgressing)
+			require.NotNil(t, progressing)
+			assert.Equal(t, "NewFirewallSetAvailable", progressing.Reason)
+
+			rolledBack := r.Target.Status.Conditions.Get(v2.FirewallDeploymentRolledBack)
+			if tt.wantRolledBack == nil {
+				assert.Nil(t, rolledBack)
+				return
+			}
+
+			require.NotNil(t, rolledBack)
+			assert.Equal(t, *tt.wantRolledBack, rolledBack.Status)
+		})
+	}
+}
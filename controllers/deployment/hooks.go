@@ -0,0 +1,277 @@
+package deployment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type hookPhase struct {
+	name          string
+	annotation    string
+	conditionType v2.ConditionType
+	// blocking phases return an error when a hook has failed, such that the rollout does not continue
+	blocking bool
+}
+
+var (
+	preRolloutPhase = hookPhase{
+		name:          "pre-rollout",
+		annotation:    v2.PreRolloutHooksAnnotation,
+		conditionType: v2.FirewallDeploymentPreRolloutHooks,
+		blocking:      true,
+	}
+	postRolloutPhase = hookPhase{
+		name:          "post-rollout",
+		annotation:    v2.PostRolloutHooksAnnotation,
+		conditionType: v2.FirewallDeploymentPostRolloutHooks,
+		blocking:      false,
+	}
+)
+
+// hookPayload is sent as the request body to http hooks.
+type hookPayload struct {
+	Phase       string `json:"phase"`
+	Namespace   string `json:"namespace"`
+	Deployment  string `json:"deployment"`
+	FirewallSet string `json:"firewallSet"`
+	Revision    int    `json:"revision"`
+}
+
+// runHooks runs the given hooks for a set one after another. the names of the hooks that have succeeded are stored
+// in an annotation of the set such that they are not run again. returns nil when all hooks have succeeded.
+func (c *controller) runHooks(r *controllers.Ctx[*v2.FirewallDeployment], set *v2.FirewallSet, phase hookPhase, hooks []v2.FirewallRolloutHook) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	succeeded := sets.New[string]()
+	if value := set.Annotations[phase.annotation]; value != "" {
+		succeeded.Insert(strings.Split(value, ",")...)
+	}
+
+	for _, hook := range hooks {
+		if succeeded.Has(hook.Name) {
+			continue
+		}
+
+		var (
+			done bool
+			err  error
+		)
+
+		switch {
+		case hook.HTTP != nil:
+			err = c.runHTTPHook(r, set, phase, hook.HTTP)
+			done = err == nil
+		case hook.Job != nil:
+			done, err = c.runJobHook(r, set, hook.Name, hook.Job)
+		default:
+			err = fmt.Errorf("neither http nor job is specified")
+		}
+
+		if err != nil {
+			r.Log.Error(err, "hook has failed", "phase", phase.name, "hook", hook.Name)
+
+			cond := v2.NewCondition(phase.conditionType, v2.ConditionFalse, "HookFailed", fmt.Sprintf("Hook %q has failed for FirewallSet %q: %s.", hook.Name, set.Name, err))
+			r.Target.Status.Conditions.Set(cond)
+
+			c.recorder.Eventf(set, nil, corev1.EventTypeWarning, "HookFailed", "running hook", "%s hook %s has failed: %s", phase.name, hook.Name, err)
+
+			if !phase.blocking {
+				return nil
+			}
+
+			return controllers.RequeueAfter(30*time.Second, fmt.Sprintf("%s hook %q has failed", phase.name, hook.Name))
+		}
+
+		if !done {
+			cond := v2.NewCondition(phase.conditionType, v2.ConditionFalse, "HookRunning", fmt.Sprintf("Hook %q is running for FirewallSet %q.", hook.Name, set.Name))
+			r.Target.Status.Conditions.Set(cond)
+
+			return controllers.RequeueAfter(10*time.Second, fmt.Sprintf("%s hook %q is running", phase.name, hook.Name))
+		}
+
+		succeeded.Insert(hook.Name)
+
+		names := succeeded.UnsortedList()
+		slices.Sort(names)
+
+		err = v2.AddAnnotation(r.Ctx, c.c.GetSeedClient(), set, phase.annotation, strings.Join(names, ","))
+		if err != nil {
+			return fmt.Errorf("unable to store succeeded hooks on firewall set: %w", err)
+		}
+
+		r.Log.Info("hook has succeeded", "phase", phase.name, "hook", hook.Name)
+
+		c.recorder.Eventf(set, nil, corev1.EventTypeNormal, "HookSucceeded", "running hook", "%s hook %s has succeeded", phase.name, hook.Name)
+	}
+
+	cond := v2.NewCondition(phase.conditionType, v2.ConditionTrue, "HooksSucceeded", fmt.Sprintf("All %s hooks have succeeded for FirewallSet %q.", phase.name, set.Name))
+	r.Target.Status.Conditions.Set(cond)
+
+	return nil
+}
+
+// markPostRolloutHooksPending is called while the latest set is replacing old sets. it adds the (empty) post-rollout hooks
+// annotation to the set such that the post-rollout hooks are run once the rollout has completed. sets that did not replace
+// any old sets while post-rollout hooks were configured never run these hooks.
+func (c *controller) markPostRolloutHooksPending(r *controllers.Ctx[*v2.FirewallDeployment], latestSet *v2.FirewallSet) error {
+	if r.Target.Spec.Hooks == nil || len(r.Target.Spec.Hooks.PostRollout) == 0 {
+		return nil
+	}
+
+	if v2.IsAnnotationPresent(latestSet, v2.PostRolloutHooksAnnotation) {
+		return nil
+	}
+
+	err := v2.AddAnnotation(r.Ctx, c.c.GetSeedClient(), latestSet, v2.PostRolloutHooksAnnotation, "")
+	if err != nil {
+		return fmt.Errorf("unable to mark post-rollout hooks as pending on firewall set: %w", err)
+	}
+
+	return nil
+}
+
+// runPostRolloutHooks runs the post-rollout hooks once the latest set has replaced the old sets and was given the shortest distance.
+// failing post-rollout hooks are reported through the deployment status but do not fail the reconciliation.
+func (c *controller) runPostRolloutHooks(r *controllers.Ctx[*v2.FirewallDeployment], ownedSets []*v2.FirewallSet, latestSet *v2.FirewallSet) error {
+	if r.Target.Spec.Hooks == nil || len(r.Target.Spec.Hooks.PostRollout) == 0 {
+		return nil
+	}
+
+	if !v2.IsAnnotationPresent(latestSet, v2.PostRolloutHooksAnnotation) {
+		return nil
+	}
+
+	if latestSet.Spec.Distance != v2.FirewallShortestDistance || len(controllers.Except(activeSets(ownedSets, latestSet), latestSet)) > 0 {
+		return nil
+	}
+
+	return c.runHooks(r, latestSet, postRolloutPhase, r.Target.Spec.Hooks.PostRollout)
+}
+
+func (c *controller) runHTTPHook(r *controllers.Ctx[*v2.FirewallDeployment], set *v2.FirewallSet, phase hookPhase, hook *v2.FirewallRolloutHTTPHook) error {
+	revision, err := controllers.Revision(set)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(hookPayload{
+		Phase:       phase.name,
+		Namespace:   r.Target.Namespace,
+		Deployment:  r.Target.Name,
+		FirewallSet: set.Name,
+		Revision:    revision,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(r.Ctx, 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("endpoint responded with status code %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// runJobHook creates a job from the job template of the referenced cron job and returns true when the job has completed.
+func (c *controller) runJobHook(r *controllers.Ctx[*v2.FirewallDeployment], set *v2.FirewallSet, name string, hook *v2.FirewallRolloutJobHook) (bool, error) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hookJobName(set, name),
+			Namespace: set.Namespace,
+		},
+	}
+
+	err := c.c.GetSeedClient().Get(r.Ctx, client.ObjectKeyFromObject(job), job)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("unable to get hook job: %w", err)
+	}
+
+	if apierrors.IsNotFound(err) {
+		cronJob := &batchv1.CronJob{}
+		err := c.c.GetSeedClient().Get(r.Ctx, client.ObjectKey{Name: hook.CronJobName, Namespace: set.Namespace}, cronJob)
+		if err != nil {
+			return false, fmt.Errorf("unable to get cron job %q: %w", hook.CronJobName, err)
+		}
+
+		job.Labels = cronJob.Spec.JobTemplate.Labels
+		job.Annotations = cronJob.Spec.JobTemplate.Annotations
+		job.OwnerReferences = []metav1.OwnerReference{
+			*metav1.NewControllerRef(set, v2.GroupVersion.WithKind("FirewallSet")),
+		}
+		job.Spec = *cronJob.Spec.JobTemplate.Spec.DeepCopy()
+
+		err = c.c.GetSeedClient().Create(r.Ctx, job)
+		if err != nil {
+			return false, fmt.Errorf("unable to create hook job: %w", err)
+		}
+
+		r.Log.Info("created hook job", "job-name", job.Name)
+
+		return false, nil
+	}
+
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			return false, fmt.Errorf("job %q has failed: %s", job.Name, cond.Message)
+		}
+	}
+
+	return false, nil
+}
+
+// hookJobName returns the name of the job of a hook. names exceeding the maximum length are truncated and suffixed with
+// a hash of the hook name such that hooks with a common prefix do not end up with the same job.
+func hookJobName(set *v2.FirewallSet, hook string) string {
+	name := fmt.Sprintf("%s-%s", set.Name, hook)
+	if len(name) <= 63 {
+		return name
+	}
+
+	hash := sha256.Sum256([]byte(hook))
+	suffix := hex.EncodeToString(hash[:])[:8]
+
+	return fmt.Sprintf("%s-%s", strings.TrimRight(name[:63-len(suffix)-1], "-"), suffix)
+}
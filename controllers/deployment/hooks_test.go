@@ -0,0 +1,345 @@
+package deployment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_controller_runHooks(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+	require.NoError(t, batchv1.AddToScheme(scheme))
+
+	var payloads []hookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p hookPayload
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		payloads = append(payloads, p)
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	deploy := &v2.FirewallDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fwdeploy",
+			Namespace: "test",
+			UID:       "deploy-uid",
+		},
+	}
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "smoke-test",
+			Namespace: "test",
+		},
+		Spec: batchv1.CronJobSpec{
+			Suspend: new(true),
+			JobTemplate: batchv1.JobTemplateSpec{
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "smoke-test", Image: "busybox"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	newJob := func(condition batchv1.JobConditionType) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "set-1-smoke-test",
+				Namespace: "test",
+			},
+			Status: batchv1.JobStatus{
+				Conditions: []batchv1.JobCondition{
+					{Type: condition, Status: corev1.ConditionTrue, Message: "something happened"},
+				},
+			},
+		}
+	}
+
+	jobHook := v2.FirewallRolloutHook{
+		Name: "smoke-test",
+		Job: &v2.FirewallRolloutJobHook{
+			CronJobName: "smoke-test",
+		},
+	}
+
+	tests := []struct {
+		name          string
+		hooks         []v2.FirewallRolloutHook
+		objs          []client.Object
+		succeeded     string
+		wantRequeue   bool
+		wantReason    string
+		wantSucceeded string
+		wantPayloads  int
+		validate      func(t *testing.T, c client.Client)
+	}{
+		{
+			name: "http hook succeeds",
+			hooks: []v2.FirewallRolloutHook{
+				{Name: "notify", HTTP: &v2.FirewallRolloutHTTPHook{URL: server.URL + "/notify"}},
+			},
+			wantReason:    "HooksSucceeded",
+			wantSucceeded: "notify",
+			wantPayloads:  1,
+		},
+		{
+			name: "http hook fails",
+			hooks: []v2.FirewallRolloutHook{
+				{Name: "notify", HTTP: &v2.FirewallRolloutHTTPHook{URL: server.URL + "/fail"}},
+			},
+			wantRequeue:  true,
+			wantReason:   "HookFailed",
+			wantPayloads: 1,
+		},
+		{
+			name: "succeeded hooks are not run again",
+			hooks: []v2.FirewallRolloutHook{
+				{Name: "notify", HTTP: &v2.FirewallRolloutHTTPHook{URL: server.URL + "/notify"}},
+				{Name: "other", HTTP: &v2.FirewallRolloutHTTPHook{URL: server.URL + "/other"}},
+			},
+			succeeded:     "notify",
+			wantReason:    "HooksSucceeded",
+			wantSucceeded: "notify,other",
+			wantPayloads:  1,
+		},
+		{
+			name:        "job hook creates job from cron job",
+			hooks:       []v2.FirewallRolloutHook{jobHook},
+			objs:        []client.Object{cronJob},
+			wantRequeue: true,
+			wantReason:  "HookRunning",
+			validate: func(t *testing.T, c client.Client) {
+				job := &batchv1.Job{}
+				require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "test", Name: "set-1-smoke-test"}, job))
+				assert.Equal(t, "smoke-test", job.Spec.Template.Spec.Containers[0].Name)
+				require.Len(t, job.OwnerReferences, 1)
+				assert.Equal(t, "set-1", job.OwnerReferences[0].Name)
+			},
+		},
+		{
+			name:          "job hook succeeds",
+			hooks:         []v2.FirewallRolloutHook{jobHook},
+			objs:          []client.Object{cronJob, newJob(batchv1.JobComplete)},
+			wantReason:    "HooksSucceeded",
+			wantSucceeded: "smoke-test",
+		},
+		{
+			name:        "job hook fails",
+			hooks:       []v2.FirewallRolloutHook{jobHook},
+			objs:        []client.Object{cronJob, newJob(batchv1.JobFailed)},
+			wantRequeue: true,
+			wantReason:  "HookFailed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloads = nil
+
+			set := newHistoryTestSet(deploy, 1, "b", 1)
+			if tt.succeeded != "" {
+				set.Annotations[v2.PreRolloutHooksAnnotation] = tt.succeeded
+			}
+
+			c := newTestController(t, scheme, append(tt.objs, set)...)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy.DeepCopy(),
+			}
+
+			err := c.runHooks(r, set, preRolloutPhase, tt.hooks)
+			if tt.wantRequeue {
+				require.Error(t, err)
+				assert.True(t, isRequeue(err), "expected requeue, got: %s", err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			cond := r.Target.Status.Conditions.Get(v2.FirewallDeploymentPreRolloutHooks)
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+
+			refetched := &v2.FirewallSet{}
+			require.NoError(t, c.c.GetSeedClient().Get(ctx, client.ObjectKeyFromObject(set), refetched))
+			if tt.wantSucceeded != "" {
+				assert.Equal(t, tt.wantSucceeded, refetched.Annotations[v2.PreRolloutHooksAnnotation])
+			}
+
+			assert.Len(t, payloads, tt.wantPayloads)
+			for _, p := range payloads {
+				assert.Equal(t, hookPayload{
+					Phase:       "pre-rollout",
+					Namespace:   "test",
+					Deployment:  "fwdeploy",
+					FirewallSet: "set-1",
+					Revision:    1,
+				}, p)
+			}
+
+			if tt.validate != nil {
+				tt.validate(t, c.c.GetSeedClient())
+			}
+		})
+	}
+}
+
+func Test_controller_runPostRolloutHooks(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		pending    bool
+		oldSet     bool
+		wantCalls  int
+		wantReason string
+	}{
+		{
+			name:      "hooks are not run for sets that did not replace old sets",
+			path:      "/notify",
+			pending:   false,
+			wantCalls: 0,
+		},
+		{
+			name:      "hooks are not run while old sets are present",
+			path:      "/notify",
+			pending:   true,
+			oldSet:    true,
+			wantCalls: 0,
+		},
+		{
+			name:       "hooks are run for sets that have replaced old sets",
+			path:       "/notify",
+			pending:    true,
+			wantCalls:  1,
+			wantReason: "HooksSucceeded",
+		},
+		{
+			name:       "failing hooks do not fail the reconciliation",
+			path:       "/fail",
+			pending:    true,
+			wantCalls:  1,
+			wantReason: "HookFailed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+
+			deploy := &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fwdeploy",
+					Namespace: "test",
+					UID:       "deploy-uid",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Hooks: &v2.FirewallRolloutHooks{
+						PostRollout: []v2.FirewallRolloutHook{
+							{Name: "notify", HTTP: &v2.FirewallRolloutHTTPHook{URL: server.URL + tt.path}},
+						},
+					},
+				},
+			}
+
+			latestSet := newHistoryTestSet(deploy, 1, "b", 1)
+			if tt.pending {
+				latestSet.Annotations[v2.PostRolloutHooksAnnotation] = ""
+			}
+
+			sets := []*v2.FirewallSet{latestSet}
+			objs := []client.Object{latestSet}
+
+			if tt.oldSet {
+				oldSet := newHistoryTestSet(deploy, 0, "a", 1)
+				sets = append(sets, oldSet)
+				objs = append(objs, oldSet)
+			}
+
+			c := newTestController(t, scheme, objs...)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy,
+			}
+
+			err := c.runPostRolloutHooks(r, sets, latestSet)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantCalls, calls)
+
+			cond := r.Target.Status.Conditions.Get(v2.FirewallDeploymentPostRolloutHooks)
+			if tt.wantReason == "" {
+				assert.Nil(t, cond)
+				return
+			}
+
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+		})
+	}
+}
+
+func Test_hookJobName(t *testing.T) {
+	set := &v2.FirewallSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "shoot--project--name-firewall-a1b2c",
+		},
+	}
+
+	assert.Equal(t, "shoot--project--name-firewall-a1b2c-smoke-test", hookJobName(set, "smoke-test"))
+
+	var (
+		a = hookJobName(set, "a-very-long-hook-name-with-a-common-prefix-one")
+		b = hookJobName(set, "a-very-long-hook-name-with-a-common-prefix-two")
+	)
+
+	assert.LessOrEqual(t, len(a), 63)
+	assert.LessOrEqual(t, len(b), 63)
+	assert.NotEqual(t, a, b)
+	assert.Equal(t, a, hookJobName(set, "a-very-long-hook-name-with-a-common-prefix-one"))
+}
@@ -3,13 +3,11 @@ package deployment
 import (
 	"encoding/json"
 	"fmt"
-	"net/netip"
 	"slices"
 	"strings"
 
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
 	"github.com/metal-stack/firewall-controller-manager/controllers"
-	"github.com/metal-stack/metal-lib/pkg/pointer"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -63,34 +61,10 @@ func (c *controller) updateInfrastructureStatus(r *controllers.Ctx[*v2.FirewallD
 		return fmt.Errorf("unable to convert gardener infrastructure object: %w", err)
 	}
 
-	var egressCIDRs []string
-
-	for _, fw := range ownedFirewalls {
-		for _, network := range fw.Status.FirewallNetworks {
-			if pointer.SafeDeref(network.NetworkType) != "external" {
-				continue
-			}
-
-			for _, ip := range network.IPs {
-				parsed, err := netip.ParseAddr(ip)
-				if err != nil {
-					continue
-				}
-
-				egressCIDRs = append(egressCIDRs, fmt.Sprintf("%s/%d", ip, parsed.BitLen()))
-			}
-		}
-	}
+	egressCIDRs := firewallEgressCIDRs(ownedFirewalls)
 
 	for _, rule := range typedInfra.Spec.ProviderConfig.Firewall.EgressRules {
-		for _, ip := range rule.IPs {
-			parsed, err := netip.ParseAddr(ip)
-			if err != nil {
-				continue
-			}
-
-			egressCIDRs = append(egressCIDRs, fmt.Sprintf("%s/%d", ip, parsed.BitLen()))
-		}
+		egressCIDRs = append(egressCIDRs, ipsToCIDRs(rule.IPs)...)
 	}
 
 	slices.Sort(egressCIDRs)
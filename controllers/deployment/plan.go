@@ -0,0 +1,61 @@
+package deployment
+
+import (
+	"slices"
+	"strings"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+)
+
+// newSetReasons returns the reasons why the current spec of the deployment requires a new firewall set.
+func newSetReasons(d *v2.FirewallDeployment, latestSet *v2.FirewallSet) []string {
+	if v2.IsAnnotationTrue(latestSet, v2.RollSetAnnotation) {
+		return []string{"set roll initiated by annotation"}
+	}
+
+	return v2.FirewallRollReasons(&latestSet.Spec.Template.Spec, &d.Spec.Template.Spec)
+}
+
+// rolloutPlan computes how the firewall sets of the deployment are changed for reaching the current spec, such that
+// users can see the impact of a template change, e.g. when the deployment is paused.
+func rolloutPlan(d *v2.FirewallDeployment, ownedSets []*v2.FirewallSet, latestSet *v2.FirewallSet) *v2.FirewallRolloutPlan {
+	var (
+		reasons = newSetReasons(d, latestSet)
+		active  = activeSets(ownedSets, latestSet)
+		plan    = &v2.FirewallRolloutPlan{
+			NewSetRequired: len(reasons) > 0,
+			Reasons:        reasons,
+		}
+		// without a new set, only the old sets of an unfinished rollout are retired
+		retiring = controllers.Except(active, latestSet)
+	)
+
+	if plan.NewSetRequired {
+		retiring = active
+
+		// the recreate strategy creates the new set with the shortest distance because the old sets are deleted first
+		distance := v2.FirewallRollingUpdateSetDistance
+		if d.Spec.Strategy == v2.StrategyRecreate {
+			distance = v2.FirewallShortestDistance
+		}
+		plan.NewSetDistance = &distance
+	}
+
+	for _, set := range retiring {
+		plan.SetsToDelete = append(plan.SetsToDelete, set.Name)
+	}
+	slices.Sort(plan.SetsToDelete)
+
+	for _, set := range active {
+		plan.Distances = append(plan.Distances, v2.FirewallSetDistance{
+			Name:     set.Name,
+			Distance: set.Spec.Distance,
+		})
+	}
+	slices.SortFunc(plan.Distances, func(a, b v2.FirewallSetDistance) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return plan
+}
@@ -0,0 +1,119 @@
+package deployment
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_rolloutPlan(t *testing.T) {
+	newSet := func(name string, revision, replicas int, distance v2.FirewallDistance, image string) *v2.FirewallSet {
+		return &v2.FirewallSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				UID:         types.UID(name),
+				Annotations: map[string]string{v2.RevisionAnnotation: strconv.Itoa(revision)},
+			},
+			Spec: v2.FirewallSetSpec{
+				Replicas: replicas,
+				Distance: distance,
+				Template: v2.FirewallTemplateSpec{
+					Spec: v2.FirewallSpec{
+						Size:     "size-a",
+						Image:    image,
+						Networks: []string{"internet"},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		strategy  v2.FirewallUpdateStrategy
+		image     string
+		ownedSets []*v2.FirewallSet
+		want      *v2.FirewallRolloutPlan
+	}{
+		{
+			name:      "no changes",
+			strategy:  v2.StrategyRollingUpdate,
+			image:     "image-a",
+			ownedSets: []*v2.FirewallSet{newSet("set-a", 0, 2, v2.FirewallShortestDistance, "image-a")},
+			want: &v2.FirewallRolloutPlan{
+				Distances: []v2.FirewallSetDistance{{Name: "set-a", Distance: v2.FirewallShortestDistance}},
+			},
+		},
+		{
+			name:     "image change with rolling update",
+			strategy: v2.StrategyRollingUpdate,
+			image:    "image-b",
+			ownedSets: []*v2.FirewallSet{
+				newSet("set-a", 0, 0, v2.FirewallShortestDistance, "image-a"),
+				newSet("set-b", 1, 2, v2.FirewallShortestDistance, "image-a"),
+			},
+			want: &v2.FirewallRolloutPlan{
+				NewSetRequired: true,
+				Reasons:        []string{`image changed from "image-a" to "image-b"`},
+				NewSetDistance: new(v2.FirewallRollingUpdateSetDistance),
+				SetsToDelete:   []string{"set-b"},
+				Distances:      []v2.FirewallSetDistance{{Name: "set-b", Distance: v2.FirewallShortestDistance}},
+			},
+		},
+		{
+			name:      "image change with recreate",
+			strategy:  v2.StrategyRecreate,
+			image:     "image-b",
+			ownedSets: []*v2.FirewallSet{newSet("set-a", 0, 2, v2.FirewallShortestDistance, "image-a")},
+			want: &v2.FirewallRolloutPlan{
+				NewSetRequired: true,
+				Reasons:        []string{`image changed from "image-a" to "image-b"`},
+				NewSetDistance: new(v2.FirewallShortestDistance),
+				SetsToDelete:   []string{"set-a"},
+				Distances:      []v2.FirewallSetDistance{{Name: "set-a", Distance: v2.FirewallShortestDistance}},
+			},
+		},
+		{
+			name:     "rollout in progress",
+			strategy: v2.StrategyRollingUpdate,
+			image:    "image-b",
+			ownedSets: []*v2.FirewallSet{
+				newSet("set-a", 0, 2, v2.FirewallShortestDistance, "image-a"),
+				newSet("set-b", 1, 2, v2.FirewallRollingUpdateSetDistance, "image-b"),
+			},
+			want: &v2.FirewallRolloutPlan{
+				SetsToDelete: []string{"set-a"},
+				Distances: []v2.FirewallSetDistance{
+					{Name: "set-a", Distance: v2.FirewallShortestDistance},
+					{Name: "set-b", Distance: v2.FirewallRollingUpdateSetDistance},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy := &v2.FirewallDeployment{
+				Spec: v2.FirewallDeploymentSpec{
+					Strategy: tt.strategy,
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Size:     "size-a",
+							Image:    tt.image,
+							Networks: []string{"internet"},
+						},
+					},
+				},
+			}
+
+			latestSet := tt.ownedSets[len(tt.ownedSets)-1]
+
+			if diff := cmp.Diff(tt.want, rolloutPlan(deploy, tt.ownedSets, latestSet)); diff != "" {
+				t.Errorf("rolloutPlan() diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package deployment
 
 import (
 	"fmt"
+	"maps"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,7 +12,6 @@ import (
 	"github.com/metal-stack/firewall-controller-manager/controllers"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -33,9 +34,36 @@ func (c *controller) Reconcile(r *controllers.Ctx[*v2.FirewallDeployment]) error
 		return err
 	}
 
+	if r.Target.Spec.Paused {
+		r.Log.Info("deployment is paused, not touching any firewall sets")
+
+		cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionUnknown, "DeploymentPaused", "Deployment is paused.")
+		r.Target.Status.Conditions.Set(cond)
+
+		// the plan shows the impact of the staged changes before the deployment is resumed
+		r.Target.Status.RolloutPlan = nil
+		if latestSet != nil {
+			r.Target.Status.RolloutPlan = rolloutPlan(r.Target, ownedSets, latestSet)
+		}
+
+		err = c.setStatus(r, ownedSets)
+		if err != nil {
+			return err
+		}
+
+		return c.updateStatusSinks(r, ownedSets)
+	}
+
+	err = c.ensureEgressIPs(r, ownedSets, latestSet)
+	if err != nil {
+		return err
+	}
+
 	if latestSet == nil {
 		r.Log.Info("no firewall set is present, creating a new one")
 
+		r.Target.Status.RolloutPlan = nil
+
 		_, err := c.createFirewallSet(r, 0, nil)
 		if err != nil {
 			return err
@@ -44,12 +72,37 @@ func (c *controller) Reconcile(r *controllers.Ctx[*v2.FirewallDeployment]) error
 		return nil
 	}
 
+	err = c.removeStaleRollSetAnnotations(r, controllers.Except(ownedSets, latestSet))
+	if err != nil {
+		return err
+	}
+
+	if v2.IsAnnotationPresent(r.Target, v2.RollbackToAnnotation) {
+		err := c.rollback(r, ownedSets, latestSet)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.syncSpares(r)
+	if err != nil {
+		return err
+	}
+
+	// the strategies below start the rollout right away, so the plan describes a rollout in progress and not a preview
+	r.Target.Status.RolloutPlan = rolloutPlan(r.Target, ownedSets, latestSet)
+
 	var reconcileErr error
-	switch s := r.Target.Spec.Strategy; s {
-	case v2.StrategyRecreate:
+	switch s := r.Target.Spec.Strategy; {
+	case latestSet.DeletionTimestamp != nil:
+		// this happens after an automatic rollback, the previous set becomes the latest set when the deletion has finished
+		reconcileErr = controllers.RequeueAfter(2*time.Second, "latest firewall set is getting deleted, waiting")
+	case s == v2.StrategyRecreate:
 		reconcileErr = c.recreateStrategy(r, ownedSets, latestSet)
-	case v2.StrategyRollingUpdate:
+	case s == v2.StrategyRollingUpdate, s == v2.StrategyAuto:
 		reconcileErr = c.rollingUpdateStrategy(r, ownedSets, latestSet)
+	case s == v2.StrategyCanary:
+		reconcileErr = c.canaryStrategy(r, ownedSets, latestSet)
 	default:
 		reconcileErr = fmt.Errorf("unknown deployment strategy: %s", s)
 	}
@@ -84,27 +137,13 @@ func (c *controller) Reconcile(r *controllers.Ctx[*v2.FirewallDeployment]) error
 		r.Log.Info("swapped latest set to shortest distance", "distance", v2.FirewallShortestDistance)
 	}
 
-	infrastructureName, ok := extractInfrastructureNameFromSeedNamespace(c.c.GetSeedNamespace())
-	if ok {
-		var ownedFirewalls []*v2.Firewall
-		for _, set := range ownedSets {
-			fws, _, err := controllers.GetOwnedResources(r.Ctx, c.c.GetSeedClient(), nil, set, &v2.FirewallList{}, func(fl *v2.FirewallList) []*v2.Firewall {
-				return fl.GetItems()
-			})
-			if err != nil {
-				return fmt.Errorf("unable to get owned firewalls: %w", err)
-			}
-
-			ownedFirewalls = append(ownedFirewalls, fws...)
-		}
-
-		err = c.updateInfrastructureStatus(r, infrastructureName, ownedFirewalls)
-		if err != nil {
-			return err
-		}
+	err = c.updateStatusSinks(r, ownedSets)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	// post-rollout hooks are run last such that they cannot block the status sinks from being updated
+	return c.runPostRolloutHooks(r, ownedSets, latestSet)
 }
 
 func (c *controller) createNextFirewallSet(r *controllers.Ctx[*v2.FirewallDeployment], set *v2.FirewallSet, ows *setOverrides) (*v2.FirewallSet, error) {
@@ -121,6 +160,8 @@ type setOverrides struct {
 	distance *v2.FirewallDistance
 	// override default replicas (inherited from set spec)
 	replicas *int
+	// additional annotations, only considered on creation
+	annotations map[string]string
 }
 
 func (c *controller) createFirewallSet(r *controllers.Ctx[*v2.FirewallDeployment], revision int, ows *setOverrides) (*v2.FirewallSet, error) {
@@ -161,16 +202,33 @@ func (c *controller) createFirewallSet(r *controllers.Ctx[*v2.FirewallDeployment
 			Labels: r.Target.Labels,
 		},
 		Spec: v2.FirewallSetSpec{
-			Replicas: replicas,
-			Template: r.Target.Spec.Template,
-			Distance: distance,
+			Replicas:         replicas,
+			Template:         firewallTemplate(r.Target),
+			Distance:         distance,
+			CreateTimeout:    r.Target.Spec.CreateTimeout,
+			HealthTimeout:    r.Target.Spec.HealthTimeout,
+			MinAvailable:     r.Target.Spec.MinAvailable,
+			RecreationBudget: r.Target.Spec.RecreationBudget,
+			Quarantine:       r.Target.Spec.Quarantine,
+			DriftRemediation: r.Target.Spec.DriftRemediation,
 		},
 	}
 
+	if ows != nil {
+		maps.Copy(set.Annotations, ows.annotations)
+	}
+	if added := addedEgressIPs(r.Target); len(added) > 0 {
+		set.Annotations[v2.AllocatedEgressIPsAnnotation] = strings.Join(added, ",")
+	}
+
 	if r.Target.Annotations != nil {
 		if val, ok := r.Target.Annotations[v2.FirewallNoControllerConnectionAnnotation]; ok {
 			set.Annotations[v2.FirewallNoControllerConnectionAnnotation] = val
 		}
+		// firewalls are only imported into the first firewall set of a deployment
+		if val, ok := r.Target.Annotations[v2.FirewallImportAnnotation]; ok && revision == 0 {
+			set.Annotations[v2.FirewallImportAnnotation] = val
+		}
 	}
 
 	err = c.c.GetSeedClient().Create(r.Ctx, set, &client.CreateOptions{})
@@ -191,16 +249,36 @@ func (c *controller) createFirewallSet(r *controllers.Ctx[*v2.FirewallDeployment
 	return set, nil
 }
 
-func (c *controller) syncFirewallSet(r *controllers.Ctx[*v2.FirewallDeployment], set *v2.FirewallSet) error {
+func (c *controller) syncFirewallSet(r *controllers.Ctx[*v2.FirewallDeployment], set *v2.FirewallSet, ows *setOverrides) error {
+	replicas := r.Target.Spec.Replicas
+	if ows != nil && ows.replicas != nil {
+		replicas = *ows.replicas
+	}
+
+	refetched := &v2.FirewallSet{}
 	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
-		refetched := &v2.FirewallSet{}
 		err := c.c.GetSeedClient().Get(r.Ctx, client.ObjectKeyFromObject(set), refetched)
 		if err != nil {
 			return fmt.Errorf("unable re-fetch firewall set: %w", err)
 		}
 
-		refetched.Spec.Replicas = r.Target.Spec.Replicas
-		refetched.Spec.Template = r.Target.Spec.Template
+		refetched.Spec.Replicas = replicas
+		refetched.Spec.Template = firewallTemplate(r.Target)
+		refetched.Spec.CreateTimeout = r.Target.Spec.CreateTimeout
+		refetched.Spec.HealthTimeout = r.Target.Spec.HealthTimeout
+		refetched.Spec.MinAvailable = r.Target.Spec.MinAvailable
+		refetched.Spec.RecreationBudget = r.Target.Spec.RecreationBudget
+		refetched.Spec.Quarantine = r.Target.Spec.Quarantine
+		refetched.Spec.DriftRemediation = r.Target.Spec.DriftRemediation
+
+		if added := addedEgressIPs(r.Target); len(added) > 0 {
+			if refetched.Annotations == nil {
+				refetched.Annotations = map[string]string{}
+			}
+			refetched.Annotations[v2.AllocatedEgressIPsAnnotation] = strings.Join(added, ",")
+		} else {
+			delete(refetched.Annotations, v2.AllocatedEgressIPsAnnotation)
+		}
 
 		err = c.c.GetSeedClient().Update(r.Ctx, refetched)
 		if err != nil {
@@ -213,6 +291,9 @@ func (c *controller) syncFirewallSet(r *controllers.Ctx[*v2.FirewallDeployment],
 		return err
 	}
 
+	// subsequent updates of the set by the caller would otherwise run into conflicts
+	refetched.DeepCopyInto(set)
+
 	r.Log.Info("updated firewall set", "set-name", set.Name)
 
 	cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionTrue, "FirewallSetUpdated", fmt.Sprintf("Updated firewall set %q.", set.Name))
@@ -224,30 +305,12 @@ func (c *controller) syncFirewallSet(r *controllers.Ctx[*v2.FirewallDeployment],
 }
 
 func (c *controller) isNewSetRequired(r *controllers.Ctx[*v2.FirewallDeployment], latestSet *v2.FirewallSet) bool {
-	if v2.IsAnnotationTrue(latestSet, v2.RollSetAnnotation) {
-		r.Log.Info("set roll initiated by annotation")
-		return true
-	}
-
-	var (
-		newS = &r.Target.Spec.Template.Spec
-		oldS = &latestSet.Spec.Template.Spec
-	)
-
-	if newS.Size != oldS.Size {
-		r.Log.Info("firewall size has changed", "size", newS.Size)
-		return true
+	reasons := newSetReasons(r.Target, latestSet)
+	if len(reasons) == 0 {
+		return false
 	}
 
-	if newS.Image != oldS.Image {
-		r.Log.Info("firewall image has changed", "image", newS.Image)
-		return true
-	}
-
-	if !sets.NewString(oldS.Networks...).Equal(sets.NewString(newS.Networks...)) {
-		r.Log.Info("firewall networks have changed", "networks", newS.Networks)
-		return true
-	}
+	r.Log.Info("new firewall set required", "reasons", reasons)
 
-	return false
+	return true
 }
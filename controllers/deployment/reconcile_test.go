@@ -0,0 +1,119 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_controller_Reconcile_paused(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	deploy := &v2.FirewallDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fwdeploy",
+			Namespace: "test",
+			UID:       "deploy-uid",
+		},
+		Spec: v2.FirewallDeploymentSpec{
+			Strategy: v2.StrategyRollingUpdate,
+			Replicas: 1,
+			Paused:   true,
+			Template: v2.FirewallTemplateSpec{
+				Spec: v2.FirewallSpec{
+					Image: "a",
+					Size:  "size-a",
+				},
+			},
+		},
+	}
+
+	set := newHistoryTestSet(deploy, 0, "a", 1)
+	set.Spec.Template.Spec.Size = "size-a"
+	set.Status.ReadyReplicas = 1
+
+	c := newTestController(t, scheme, deploy, set)
+
+	listSets := func(t *testing.T) []v2.FirewallSet {
+		sets := &v2.FirewallSetList{}
+		require.NoError(t, c.c.GetSeedClient().List(ctx, sets, client.InNamespace("test")))
+		return sets.Items
+	}
+
+	reconcile := func(t *testing.T) {
+		refetched := &v2.FirewallDeployment{}
+		require.NoError(t, c.c.GetSeedClient().Get(ctx, client.ObjectKeyFromObject(deploy), refetched))
+
+		// staged template changes while the deployment is paused
+		refetched.Spec = deploy.Spec
+		require.NoError(t, c.c.GetSeedClient().Update(ctx, refetched))
+
+		r := &controllers.Ctx[*v2.FirewallDeployment]{
+			Ctx:    ctx,
+			Log:    testr.New(t),
+			Target: refetched,
+		}
+
+		require.NoError(t, c.Reconcile(r))
+
+		deploy.Status = r.Target.Status
+	}
+
+	t.Run("template changes are not rolled out while paused", func(t *testing.T) {
+		deploy.Spec.Template.Spec.Image = "b"
+		reconcile(t)
+
+		deploy.Spec.Template.Spec.Size = "size-b"
+		reconcile(t)
+
+		sets := listSets(t)
+		require.Len(t, sets, 1)
+		assert.Equal(t, set.Name, sets[0].Name)
+		assert.Equal(t, "a", sets[0].Spec.Template.Spec.Image)
+		assert.Equal(t, "size-a", sets[0].Spec.Template.Spec.Size)
+		assert.Equal(t, set.ResourceVersion, sets[0].ResourceVersion)
+
+		assert.Equal(t, 1, deploy.Status.TargetReplicas)
+		assert.Equal(t, 1, deploy.Status.ReadyReplicas)
+
+		cond := deploy.Status.Conditions.Get(v2.FirewallDeploymentProgressing)
+		require.NotNil(t, cond)
+		assert.Equal(t, "DeploymentPaused", cond.Reason)
+
+		available := deploy.Status.Conditions.Get(v2.FirewallDeploymentAvailable)
+		require.NotNil(t, available)
+		assert.Equal(t, v2.ConditionTrue, available.Status)
+	})
+
+	t.Run("staged changes are rolled out as a single set after resume", func(t *testing.T) {
+		deploy.Spec.Paused = false
+		reconcile(t)
+		reconcile(t)
+
+		sets := listSets(t)
+		require.Len(t, sets, 2)
+
+		var newSets []v2.FirewallSet
+		for _, s := range sets {
+			if s.Name != set.Name {
+				newSets = append(newSets, s)
+			}
+		}
+
+		require.Len(t, newSets, 1)
+		assert.Equal(t, "b", newSets[0].Spec.Template.Spec.Image)
+		assert.Equal(t, "size-b", newSets[0].Spec.Template.Spec.Size)
+		assert.Equal(t, "1", newSets[0].Annotations[v2.RevisionAnnotation])
+	})
+}
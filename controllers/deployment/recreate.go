@@ -15,9 +15,18 @@ func (c *controller) recreateStrategy(r *controllers.Ctx[*v2.FirewallDeployment]
 	if c.isNewSetRequired(r, latestSet) {
 		r.Log.Info("significant changes detected in the spec, create new scaled down firewall set, then cleaning up old sets")
 
-		set, err := c.createNextFirewallSet(r, latestSet, &setOverrides{
+		ows := &setOverrides{
 			replicas: new(0),
-		})
+		}
+
+		// the auto strategy needs to remember the fallback until the old sets are gone
+		if r.Target.Spec.Strategy == v2.StrategyAuto {
+			ows.annotations = map[string]string{
+				v2.RecreateFallbackAnnotation: "true",
+			}
+		}
+
+		set, err := c.createNextFirewallSet(r, latestSet, ows)
 		if err != nil {
 			return err
 		}
@@ -27,12 +36,12 @@ func (c *controller) recreateStrategy(r *controllers.Ctx[*v2.FirewallDeployment]
 		latestSet = set
 	}
 
-	err := c.deleteFirewallSets(r, controllers.Except(ownedSets, latestSet)...)
+	err := c.retireOldSets(r, ownedSets, latestSet)
 	if err != nil {
 		return err
 	}
 
-	err = c.syncFirewallSet(r, latestSet)
+	err = c.syncFirewallSet(r, latestSet, nil)
 	if err != nil {
 		return fmt.Errorf("unable to update firewall set: %w", err)
 	}
@@ -40,7 +49,7 @@ func (c *controller) recreateStrategy(r *controllers.Ctx[*v2.FirewallDeployment]
 	if latestSet.Status.ReadyReplicas != latestSet.Spec.Replicas {
 		r.Log.Info("set replicas are not yet ready")
 
-		if time.Since(latestSet.CreationTimestamp.Time) > c.c.GetProgressDeadline() {
+		if time.Since(latestSet.CreationTimestamp.Time) > c.c.GetProgressDeadlineFor(r.Target) {
 			cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionFalse, "ProgressDeadlineExceeded", fmt.Sprintf("FirewallSet %q has timed out progressing.", latestSet.Name))
 			r.Target.Status.Conditions.Set(cond)
 		}
@@ -48,8 +57,7 @@ func (c *controller) recreateStrategy(r *controllers.Ctx[*v2.FirewallDeployment]
 		return nil
 	}
 
-	cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionTrue, "NewFirewallSetAvailable", fmt.Sprintf("FirewallSet %q has successfully progressed.", latestSet.Name))
-	r.Target.Status.Conditions.Set(cond)
+	setRolloutCompleted(r, latestSet)
 
 	return nil
 }
@@ -2,21 +2,52 @@ package deployment
 
 import (
 	"fmt"
+	"slices"
 	"time"
 
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/defaults"
 	"github.com/metal-stack/firewall-controller-manager/controllers"
 	corev1 "k8s.io/api/core/v1"
 )
 
 // rollingUpdateStrategy first creates a new set and deletes the old one's when the new one becomes ready
+//
+// if max surge and max unavailable are configured, the firewalls of the old sets are replaced gradually
+//
+// with the auto strategy, the old sets are recreated instead if there are not enough free machines for the new set
 func (c *controller) rollingUpdateStrategy(r *controllers.Ctx[*v2.FirewallDeployment], ownedSets []*v2.FirewallSet, latestSet *v2.FirewallSet) error {
+	if r.Target.Spec.Strategy == v2.StrategyAuto && isRecreateFallback(ownedSets, latestSet) {
+		r.Log.Info("latest set was created by falling back to recreate strategy, continuing until the old sets are gone")
+
+		return c.recreateStrategy(r, ownedSets, latestSet)
+	}
+
 	if c.isNewSetRequired(r, latestSet) {
+		ows := &setOverrides{
+			distance: v2.FirewallRollingUpdateSetDistance.Pointer(),
+		}
+
+		replicas := r.Target.Spec.Replicas
+		if r.Target.Spec.RollingUpdate != nil {
+			replicas = surgeReplicas(r.Target, 0, activeSets(ownedSets, latestSet))
+			ows.replicas = &replicas
+		}
+
+		if !c.checkCapacity(r, replicas) {
+			if r.Target.Spec.Strategy == v2.StrategyAuto {
+				r.Log.Info("not enough capacity for a rolling update, falling back to recreate strategy")
+				c.recorder.Eventf(r.Target, nil, corev1.EventTypeNormal, "StrategyFallback", "falling back to recreate", "not enough free machines for a rolling update, recreating firewall set %s", latestSet.Name)
+
+				return c.recreateStrategy(r, ownedSets, latestSet)
+			}
+
+			c.recorder.Eventf(r.Target, nil, corev1.EventTypeWarning, "NotEnoughCapacity", "checking capacity", "not enough free machines for the new firewall set, it may not become ready")
+		}
+
 		r.Log.Info("significant changes detected in the spec, creating new firewall set", "distance", v2.FirewallRollingUpdateSetDistance)
 
-		newSet, err := c.createNextFirewallSet(r, latestSet, &setOverrides{
-			distance: v2.FirewallRollingUpdateSetDistance.Pointer(),
-		})
+		newSet, err := c.createNextFirewallSet(r, latestSet, ows)
 		if err != nil {
 			return err
 		}
@@ -28,7 +59,19 @@ func (c *controller) rollingUpdateStrategy(r *controllers.Ctx[*v2.FirewallDeploy
 		return c.cleanupIntermediateSets(r, ownedSets)
 	}
 
-	err := c.syncFirewallSet(r, latestSet)
+	oldSets := controllers.Except(activeSets(ownedSets, latestSet), latestSet)
+	if len(oldSets) > 0 {
+		err := c.markPostRolloutHooksPending(r, latestSet)
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.Target.Spec.RollingUpdate != nil && len(oldSets) > 0 {
+		return c.rollGradually(r, ownedSets, oldSets, latestSet)
+	}
+
+	err := c.syncFirewallSet(r, latestSet, nil)
 	if err != nil {
 		return fmt.Errorf("unable to update firewall set: %w", err)
 	}
@@ -36,34 +79,249 @@ func (c *controller) rollingUpdateStrategy(r *controllers.Ctx[*v2.FirewallDeploy
 	if latestSet.Status.ReadyReplicas != latestSet.Spec.Replicas {
 		r.Log.Info("set replicas are not yet ready")
 
-		if time.Since(latestSet.CreationTimestamp.Time) > c.c.GetProgressDeadline() {
+		if time.Since(latestSet.CreationTimestamp.Time) > c.c.GetProgressDeadlineFor(r.Target) {
 			cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionFalse, "ProgressDeadlineExceeded", fmt.Sprintf("FirewallSet %q has timed out progressing.", latestSet.Name))
 			r.Target.Status.Conditions.Set(cond)
+
+			if r.Target.Spec.AutoRollback {
+				return c.autoRollback(r, ownedSets, latestSet)
+			}
 		}
 
 		return c.cleanupIntermediateSets(r, ownedSets)
 	}
 
-	cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionTrue, "NewFirewallSetAvailable", fmt.Sprintf("FirewallSet %q has successfully progressed.", latestSet.Name))
-	r.Target.Status.Conditions.Set(cond)
+	if len(oldSets) > 0 {
+		err := c.cutover(r, latestSet)
+		if err != nil {
+			return err
+		}
+	}
+
+	setRolloutCompleted(r, latestSet)
 
 	r.Log.Info("ensuring old sets are cleaned up")
 
-	oldSets := controllers.Except(ownedSets, latestSet)
+	err = c.retireOldSets(r, ownedSets, latestSet)
+	if err != nil {
+		return err
+	}
 
-	return c.deleteFirewallSets(r, oldSets...)
+	return nil
+}
+
+// isRecreateFallback returns true if the latest set was created by falling back to the recreate strategy and firewalls
+// of the old sets are still present. scaling up the latest set would require the machines that are still held by the old sets.
+func isRecreateFallback(ownedSets []*v2.FirewallSet, latestSet *v2.FirewallSet) bool {
+	if !v2.IsAnnotationTrue(latestSet, v2.RecreateFallbackAnnotation) {
+		return false
+	}
+
+	for _, set := range controllers.Except(ownedSets, latestSet) {
+		if set.DeletionTimestamp != nil || set.Spec.Replicas > 0 {
+			return true
+		}
+		if set.Status.ReadyReplicas+set.Status.ProgressingReplicas+set.Status.UnhealthyReplicas > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rollGradually scales up the latest set and scales down the old sets step by step such that the amount of firewalls
+// does not exceed the desired replicas by max surge and the amount of ready firewalls does not fall below the desired
+// replicas by max unavailable.
+func (c *controller) rollGradually(r *controllers.Ctx[*v2.FirewallDeployment], ownedSets, oldSets []*v2.FirewallSet, latestSet *v2.FirewallSet) error {
+	var (
+		desired           = r.Target.Spec.Replicas
+		_, maxUnavailable = rollingUpdateLimits(r.Target)
+		newReplicas       = surgeReplicas(r.Target, latestSet.Spec.Replicas, oldSets)
+		oldReplicas       int
+		oldReady          int
+	)
+
+	for _, set := range oldSets {
+		oldReplicas += set.Spec.Replicas
+		oldReady += set.Status.ReadyReplicas
+	}
+
+	err := c.syncFirewallSet(r, latestSet, &setOverrides{
+		replicas: &newReplicas,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to update firewall set: %w", err)
+	}
+
+	// a gradual roll consists of many steps, so the progress deadline applies to every step and not to the entire roll
+	lastProgress, err := c.lastProgressOf(r, latestSet)
+	if err != nil {
+		return err
+	}
+
+	if time.Since(lastProgress) > c.c.GetProgressDeadlineFor(r.Target) {
+		cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionFalse, "ProgressDeadlineExceeded", fmt.Sprintf("FirewallSet %q has timed out progressing.", latestSet.Name))
+		r.Target.Status.Conditions.Set(cond)
+
+		if r.Target.Spec.AutoRollback {
+			return c.autoRollback(r, ownedSets, latestSet)
+		}
+	} else {
+		cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionTrue, "FirewallSetRolling", fmt.Sprintf("FirewallSet %q has %d of %d firewalls ready, replacing firewalls of old sets gradually.", latestSet.Name, latestSet.Status.ReadyReplicas, desired))
+		r.Target.Status.Conditions.Set(cond)
+	}
+
+	var (
+		minAvailable = desired - maxUnavailable
+		available    = latestSet.Status.ReadyReplicas + oldReady
+		// firewalls of the old sets which are not ready can always be removed
+		unready    = max(0, oldReplicas-oldReady)
+		scaleDown  = min(oldReplicas, unready+max(0, available-minAvailable))
+		cutoverErr error
+	)
+
+	// ready firewalls of the old sets are only removed after the cutover to the ready firewalls of the latest set
+	if scaleDown > unready && isCutoverRequired(r.Target) {
+		if newReplicas == 0 || latestSet.Status.ReadyReplicas < newReplicas {
+			cutoverErr = controllers.RequeueAfter(10*time.Second, "waiting for firewalls of latest set to become ready before cutover")
+		} else {
+			cutoverErr = c.cutover(r, latestSet)
+		}
+
+		if cutoverErr != nil {
+			r.Log.Info("not scaling down ready firewalls of old sets before cutover")
+			scaleDown = unready
+		}
+	}
+
+	if scaleDown > 0 {
+		sorted, err := sortByRevisionDescending(oldSets)
+		if err != nil {
+			return err
+		}
+
+		// oldest sets are scaled down first
+		for _, set := range slices.Backward(sorted) {
+			if scaleDown == 0 {
+				break
+			}
+
+			n := min(set.Spec.Replicas, scaleDown)
+			if n == 0 {
+				continue
+			}
+
+			err := c.scaleFirewallSet(r, set, set.Spec.Replicas-n)
+			if err != nil {
+				return err
+			}
+
+			scaleDown -= n
+		}
+	}
+
+	if cutoverErr != nil {
+		return cutoverErr
+	}
+
+	r.Log.Info("gradually replacing firewalls of old sets", "new-replicas", newReplicas, "new-ready", latestSet.Status.ReadyReplicas, "old-replicas", oldReplicas, "old-ready", oldReady)
+
+	return controllers.RequeueAfter(10*time.Second, "replacing firewalls of old sets gradually")
+}
+
+// cutover runs the pre-rollout hooks and shifts the traffic to the latest set if configured. returns nil when the
+// firewalls of the old sets can be removed.
+func (c *controller) cutover(r *controllers.Ctx[*v2.FirewallDeployment], latestSet *v2.FirewallSet) error {
+	if r.Target.Spec.Hooks != nil {
+		err := c.runHooks(r, latestSet, preRolloutPhase, r.Target.Spec.Hooks.PreRollout)
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.Target.Spec.StepwiseTrafficShift {
+		err := c.shiftTraffic(r, latestSet)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isCutoverRequired(d *v2.FirewallDeployment) bool {
+	return d.Spec.StepwiseTrafficShift || (d.Spec.Hooks != nil && len(d.Spec.Hooks.PreRollout) > 0)
+}
+
+// lastProgressOf returns the last time the given set has made progress, which is the time when the set or one of its
+// firewalls was created or when one of its firewalls was provisioned.
+func (c *controller) lastProgressOf(r *controllers.Ctx[*v2.FirewallDeployment], set *v2.FirewallSet) (time.Time, error) {
+	fws, _, err := controllers.GetOwnedResources(r.Ctx, c.c.GetSeedClient(), set.Spec.Selector, set, &v2.FirewallList{}, func(fl *v2.FirewallList) []*v2.Firewall {
+		return fl.GetItems()
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to get owned firewalls: %w", err)
+	}
+
+	lastProgress := set.CreationTimestamp.Time
+
+	for _, fw := range fws {
+		if fw.CreationTimestamp.After(lastProgress) {
+			lastProgress = fw.CreationTimestamp.Time
+		}
+
+		cond := fw.Status.Conditions.Get(v2.FirewallProvisioned)
+		if cond != nil && cond.Status == v2.ConditionTrue && cond.LastTransitionTime.After(lastProgress) {
+			lastProgress = cond.LastTransitionTime.Time
+		}
+	}
+
+	return lastProgress, nil
+}
+
+// surgeReplicas returns the amount of replicas for the latest set that is allowed by max surge considering the replicas of the old sets.
+func surgeReplicas(d *v2.FirewallDeployment, current int, oldSets []*v2.FirewallSet) int {
+	var (
+		desired     = d.Spec.Replicas
+		maxSurge, _ = rollingUpdateLimits(d)
+		oldReplicas int
+	)
+
+	for _, set := range oldSets {
+		oldReplicas += set.Spec.Replicas
+	}
+
+	return min(desired, max(current, desired+maxSurge-oldReplicas))
+}
+
+func rollingUpdateLimits(d *v2.FirewallDeployment) (maxSurge int, maxUnavailable int) {
+	maxSurge, maxUnavailable = defaults.DefaultMaxSurge, defaults.DefaultMaxUnavailable
+
+	if ru := d.Spec.RollingUpdate; ru != nil {
+		if ru.MaxSurge != nil {
+			maxSurge = *ru.MaxSurge
+		}
+		if ru.MaxUnavailable != nil {
+			maxUnavailable = *ru.MaxUnavailable
+		}
+	}
+
+	return maxSurge, maxUnavailable
 }
 
 func (c *controller) cleanupIntermediateSets(r *controllers.Ctx[*v2.FirewallDeployment], sets []*v2.FirewallSet) error {
 	// the idea is to keep the oldest and the latest set such that unfinished updates "in the middle" are cleaned up
 	// prevents e.g. more than one firewall getting provisioned when triggering multiple spec changes quickly
 
-	oldestSet, err := controllers.MinRevisionOf(sets)
+	latestSet, err := controllers.MaxRevisionOf(sets)
 	if err != nil {
 		return err
 	}
 
-	latestSet, err := controllers.MaxRevisionOf(sets)
+	// sets in the revision history are not considered
+	sets = activeSets(sets, latestSet)
+
+	oldestSet, err := controllers.MinRevisionOf(sets)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,477 @@
+package deployment
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_controller_rollingUpdateStrategy_progressDeadline(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	tests := []struct {
+		name           string
+		autoRollback   bool
+		wantRequeue    bool
+		wantSets       []string
+		wantImage      string
+		wantRolledBack bool
+	}{
+		{
+			name:           "progress deadline exceeded without auto rollback",
+			autoRollback:   false,
+			wantRequeue:    false,
+			wantSets:       []string{"set-0", "set-1"},
+			wantImage:      "b",
+			wantRolledBack: false,
+		},
+		{
+			name:           "progress deadline exceeded with auto rollback",
+			autoRollback:   true,
+			wantRequeue:    true,
+			wantSets:       []string{"set-0"},
+			wantImage:      "a",
+			wantRolledBack: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy := &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fwdeploy",
+					Namespace: "test",
+					UID:       "deploy-uid",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Strategy:     v2.StrategyRollingUpdate,
+					Replicas:     1,
+					AutoRollback: tt.autoRollback,
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Image: "b",
+						},
+					},
+				},
+			}
+
+			oldSet := newHistoryTestSet(deploy, 0, "a", 1)
+			oldSet.Status.ReadyReplicas = 1
+
+			newSet := newHistoryTestSet(deploy, 1, "b", 1)
+			newSet.CreationTimestamp = metav1.NewTime(time.Now().Add(-1 * time.Hour))
+			newSet.Spec.Distance = v2.FirewallRollingUpdateSetDistance
+			newSet.Status.ProgressingReplicas = 1
+
+			sets := []*v2.FirewallSet{oldSet, newSet}
+
+			c := newTestController(t, scheme, deploy, oldSet, newSet)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy,
+			}
+
+			err := c.rollingUpdateStrategy(r, sets, newSet)
+			if tt.wantRequeue {
+				require.Error(t, err)
+				assert.True(t, isRequeue(err), "expected requeue, got: %s", err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			cond := r.Target.Status.Conditions.Get(v2.FirewallDeploymentProgressing)
+			require.NotNil(t, cond)
+			assert.Equal(t, "ProgressDeadlineExceeded", cond.Reason)
+
+			rolledBack := r.Target.Status.Conditions.Get(v2.FirewallDeploymentRolledBack)
+			if tt.wantRolledBack {
+				require.NotNil(t, rolledBack)
+				assert.Equal(t, v2.ConditionTrue, rolledBack.Status)
+			} else {
+				assert.Nil(t, rolledBack)
+			}
+
+			setList := &v2.FirewallSetList{}
+			require.NoError(t, c.c.GetSeedClient().List(ctx, setList, client.InNamespace("test")))
+
+			var got []string
+			for _, set := range setList.Items {
+				got = append(got, set.Name)
+			}
+			assert.ElementsMatch(t, tt.wantSets, got)
+
+			refetched := &v2.FirewallDeployment{}
+			require.NoError(t, c.c.GetSeedClient().Get(ctx, client.ObjectKeyFromObject(deploy), refetched))
+			assert.Equal(t, tt.wantImage, refetched.Spec.Template.Spec.Image)
+		})
+	}
+}
+
+func Test_controller_rollingUpdateStrategy_gradual(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	type setState struct {
+		replicas int
+		ready    int
+	}
+
+	tests := []struct {
+		name           string
+		maxSurge       int
+		maxUnavailable int
+		oldSet         setState
+		newSet         *setState
+		wantOld        int
+		wantNew        int
+	}{
+		{
+			name:           "new set is created with surge replicas",
+			maxSurge:       1,
+			maxUnavailable: 0,
+			oldSet:         setState{replicas: 3, ready: 3},
+			newSet:         nil,
+			wantOld:        3,
+			wantNew:        1,
+		},
+		{
+			name:           "new set is created without replicas when surge is not allowed",
+			maxSurge:       0,
+			maxUnavailable: 1,
+			oldSet:         setState{replicas: 3, ready: 3},
+			newSet:         nil,
+			wantOld:        3,
+			wantNew:        0,
+		},
+		{
+			name:           "old set is not scaled down until new firewall is ready",
+			maxSurge:       1,
+			maxUnavailable: 0,
+			oldSet:         setState{replicas: 3, ready: 3},
+			newSet:         &setState{replicas: 1, ready: 0},
+			wantOld:        3,
+			wantNew:        1,
+		},
+		{
+			name:           "old set is scaled down when new firewall is ready",
+			maxSurge:       1,
+			maxUnavailable: 0,
+			oldSet:         setState{replicas: 3, ready: 3},
+			newSet:         &setState{replicas: 1, ready: 1},
+			wantOld:        2,
+			wantNew:        1,
+		},
+		{
+			name:           "new set is scaled up after old set was scaled down",
+			maxSurge:       1,
+			maxUnavailable: 0,
+			oldSet:         setState{replicas: 2, ready: 2},
+			newSet:         &setState{replicas: 1, ready: 1},
+			wantOld:        2,
+			wantNew:        2,
+		},
+		{
+			name:           "old set is scaled down first when unavailability is allowed",
+			maxSurge:       0,
+			maxUnavailable: 1,
+			oldSet:         setState{replicas: 3, ready: 3},
+			newSet:         &setState{replicas: 0, ready: 0},
+			wantOld:        2,
+			wantNew:        0,
+		},
+		{
+			name:           "unready firewalls of the old set are removed",
+			maxSurge:       1,
+			maxUnavailable: 0,
+			oldSet:         setState{replicas: 3, ready: 2},
+			newSet:         &setState{replicas: 1, ready: 0},
+			wantOld:        2,
+			wantNew:        1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy := &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fwdeploy",
+					Namespace: "test",
+					UID:       "deploy-uid",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Strategy: v2.StrategyRollingUpdate,
+					Replicas: 3,
+					RollingUpdate: &v2.FirewallRollingUpdate{
+						MaxSurge:       new(tt.maxSurge),
+						MaxUnavailable: new(tt.maxUnavailable),
+					},
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Image: "b",
+						},
+					},
+				},
+			}
+
+			oldSet := newHistoryTestSet(deploy, 0, "a", tt.oldSet.replicas)
+			oldSet.Status.ReadyReplicas = tt.oldSet.ready
+
+			sets := []*v2.FirewallSet{oldSet}
+			objs := []client.Object{deploy, oldSet}
+
+			if tt.newSet != nil {
+				newSet := newHistoryTestSet(deploy, 1, "b", tt.newSet.replicas)
+				newSet.CreationTimestamp = metav1.Now()
+				newSet.Spec.Distance = v2.FirewallRollingUpdateSetDistance
+				newSet.Status.ReadyReplicas = tt.newSet.ready
+
+				sets = append(sets, newSet)
+				objs = append(objs, newSet)
+			}
+
+			c := newTestController(t, scheme, objs...)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy,
+			}
+
+			latestSet, err := controllers.MaxRevisionOf(sets)
+			require.NoError(t, err)
+
+			_ = c.rollingUpdateStrategy(r, sets, latestSet)
+
+			setList := &v2.FirewallSetList{}
+			require.NoError(t, c.c.GetSeedClient().List(ctx, setList, client.InNamespace("test")))
+			require.Len(t, setList.Items, 2)
+
+			for _, set := range setList.Items {
+				if set.Name == oldSet.Name {
+					assert.Equal(t, tt.wantOld, set.Spec.Replicas, "old set replicas")
+				} else {
+					assert.Equal(t, tt.wantNew, set.Spec.Replicas, "new set replicas")
+				}
+			}
+		})
+	}
+}
+
+func Test_controller_rollingUpdateStrategy_gradualProgressDeadline(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	tests := []struct {
+		name           string
+		provisionedAgo time.Duration
+		wantReason     string
+	}{
+		{
+			name:           "roll taking longer than the progress deadline is fine as long as firewalls become ready",
+			provisionedAgo: 2 * time.Minute,
+			wantReason:     "FirewallSetRolling",
+		},
+		{
+			name:           "no firewall became ready within the progress deadline",
+			provisionedAgo: 50 * time.Minute,
+			wantReason:     "ProgressDeadlineExceeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy := &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fwdeploy",
+					Namespace: "test",
+					UID:       "deploy-uid",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Strategy: v2.StrategyRollingUpdate,
+					Replicas: 3,
+					RollingUpdate: &v2.FirewallRollingUpdate{
+						MaxSurge:       new(1),
+						MaxUnavailable: new(0),
+					},
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Image: "b",
+						},
+					},
+				},
+			}
+
+			oldSet := newHistoryTestSet(deploy, 0, "a", 2)
+			oldSet.Status.ReadyReplicas = 2
+
+			newSet := newHistoryTestSet(deploy, 1, "b", 1)
+			newSet.CreationTimestamp = metav1.NewTime(time.Now().Add(-1 * time.Hour))
+			newSet.Spec.Distance = v2.FirewallRollingUpdateSetDistance
+			newSet.Status.ReadyReplicas = 1
+
+			fw := &v2.Firewall{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "fw-1",
+					Namespace:         "test",
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-1 * time.Hour)),
+					OwnerReferences: []metav1.OwnerReference{
+						*metav1.NewControllerRef(newSet, v2.GroupVersion.WithKind("FirewallSet")),
+					},
+				},
+				Status: v2.FirewallStatus{
+					Conditions: v2.Conditions{
+						{
+							Type:               v2.FirewallProvisioned,
+							Status:             v2.ConditionTrue,
+							LastTransitionTime: metav1.NewTime(time.Now().Add(-tt.provisionedAgo)),
+						},
+					},
+				},
+			}
+
+			c := newTestController(t, scheme, deploy, oldSet, newSet, fw)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy,
+			}
+
+			err := c.rollingUpdateStrategy(r, []*v2.FirewallSet{oldSet, newSet}, newSet)
+			require.Error(t, err)
+			assert.True(t, isRequeue(err), "expected requeue, got: %s", err)
+
+			cond := r.Target.Status.Conditions.Get(v2.FirewallDeploymentProgressing)
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+		})
+	}
+}
+
+func Test_controller_rollingUpdateStrategy_gradualCutover(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		hookPath   string
+		newReady   int
+		wantOld    int
+		wantReason string
+	}{
+		{
+			name:       "old set is not scaled down before new firewalls are ready",
+			hookPath:   "/notify",
+			newReady:   0,
+			wantOld:    3,
+			wantReason: "FirewallSetRolling",
+		},
+		{
+			name:       "old set is not scaled down when pre-rollout hook fails",
+			hookPath:   "/fail",
+			newReady:   1,
+			wantOld:    3,
+			wantReason: "HookFailed",
+		},
+		{
+			name:       "old set is scaled down after pre-rollout hook has succeeded",
+			hookPath:   "/notify",
+			newReady:   1,
+			wantOld:    2,
+			wantReason: "HooksSucceeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy := &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fwdeploy",
+					Namespace: "test",
+					UID:       "deploy-uid",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Strategy: v2.StrategyRollingUpdate,
+					Replicas: 3,
+					RollingUpdate: &v2.FirewallRollingUpdate{
+						MaxSurge:       new(1),
+						MaxUnavailable: new(0),
+					},
+					Hooks: &v2.FirewallRolloutHooks{
+						PreRollout: []v2.FirewallRolloutHook{
+							{Name: "notify", HTTP: &v2.FirewallRolloutHTTPHook{URL: server.URL + tt.hookPath}},
+						},
+					},
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Image: "b",
+						},
+					},
+				},
+			}
+
+			oldSet := newHistoryTestSet(deploy, 0, "a", 3)
+			oldSet.Status.ReadyReplicas = 3
+
+			newSet := newHistoryTestSet(deploy, 1, "b", 1)
+			newSet.CreationTimestamp = metav1.Now()
+			newSet.Spec.Distance = v2.FirewallRollingUpdateSetDistance
+			newSet.Status.ReadyReplicas = tt.newReady
+
+			c := newTestController(t, scheme, deploy, oldSet, newSet)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy,
+			}
+
+			err := c.rollingUpdateStrategy(r, []*v2.FirewallSet{oldSet, newSet}, newSet)
+			require.Error(t, err)
+			assert.True(t, isRequeue(err), "expected requeue, got: %s", err)
+
+			refetched := &v2.FirewallSet{}
+			require.NoError(t, c.c.GetSeedClient().Get(ctx, client.ObjectKeyFromObject(oldSet), refetched))
+			assert.Equal(t, tt.wantOld, refetched.Spec.Replicas)
+
+			cond := r.Target.Status.Conditions.Get(v2.FirewallDeploymentProgressing)
+			if tt.wantReason == "FirewallSetRolling" {
+				require.NotNil(t, cond)
+				assert.Equal(t, tt.wantReason, cond.Reason)
+				return
+			}
+
+			cond = r.Target.Status.Conditions.Get(v2.FirewallDeploymentPreRolloutHooks)
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+		})
+	}
+}
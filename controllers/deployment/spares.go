@@ -0,0 +1,180 @@
+package deployment
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/uuid"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// syncSpares keeps the configured amount of spare firewalls allocated for the deployment.
+//
+// spares are not owned by any set and have the longest distance such that they do not attract any traffic. sets of
+// the deployment adopt them on scale up instead of allocating new machines. spares that do not match the template
+// anymore or that have timed out are replaced.
+func (c *controller) syncSpares(r *controllers.Ctx[*v2.FirewallDeployment]) error {
+	spares, err := c.getSpares(r)
+	if err != nil {
+		return err
+	}
+
+	var (
+		createTimeout = c.c.GetCreateTimeoutForDeployment(r.Target)
+		healthTimeout = c.c.GetFirewallHealthTimeoutForDeployment(r.Target)
+		valid         []*v2.Firewall
+		toDelete      []*v2.Firewall
+	)
+
+	for _, spare := range spares {
+		if spare.DeletionTimestamp != nil {
+			continue
+		}
+
+		if !controllers.IsSpareCompatible(spare, &r.Target.Spec.Template.Spec) {
+			r.Log.Info("spare firewall does not match the template anymore, replacing", "firewall-name", spare.Name)
+			toDelete = append(toDelete, spare)
+			continue
+		}
+
+		status := v2.EvaluateFirewallStatus(spare, createTimeout, healthTimeout)
+		if status.Result == v2.FirewallStatusCreateTimeout || status.Result == v2.FirewallStatusHealthTimeout {
+			r.Log.Info("spare firewall has timed out, replacing", "firewall-name", spare.Name, "reason", status.Reason)
+			toDelete = append(toDelete, spare)
+			continue
+		}
+
+		valid = append(valid, spare)
+	}
+
+	// ready spares are kept on scale down
+	controllers.SortSparesByReadiness(valid)
+
+	if len(valid) > r.Target.Spec.Spares {
+		toDelete = append(toDelete, valid[r.Target.Spec.Spares:]...)
+		valid = valid[:r.Target.Spec.Spares]
+	}
+
+	r.Target.Status.ReadySpareReplicas = 0
+	for _, spare := range valid {
+		if controllers.IsSpareReady(spare) {
+			r.Target.Status.ReadySpareReplicas++
+		}
+	}
+
+	for _, spare := range toDelete {
+		err := c.c.GetSeedClient().Delete(r.Ctx, spare)
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("unable to delete spare firewall: %w", err)
+		}
+
+		c.recorder.Eventf(spare, nil, corev1.EventTypeNormal, "Delete", "deleting spare", "deleted spare firewall %s", spare.Name)
+	}
+
+	for i := len(valid); i < r.Target.Spec.Spares; i++ {
+		spare, err := c.createSpare(r)
+		if err != nil {
+			return err
+		}
+
+		r.Log.Info("created spare firewall", "firewall-name", spare.Name)
+
+		c.recorder.Eventf(r.Target, nil, corev1.EventTypeNormal, "Create", "creating spare", "created spare firewall %s", spare.Name)
+
+		valid = append(valid, spare)
+	}
+
+	r.Target.Status.SpareReplicas = len(valid)
+
+	return nil
+}
+
+func (c *controller) getSpares(r *controllers.Ctx[*v2.FirewallDeployment]) ([]*v2.Firewall, error) {
+	fwList := &v2.FirewallList{}
+	err := c.c.GetSeedClient().List(r.Ctx, fwList, client.InNamespace(r.Target.Namespace), client.MatchingLabels{
+		v2.FirewallSpareLabel: r.Target.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list spare firewalls: %w", err)
+	}
+
+	return fwList.GetItems(), nil
+}
+
+func (c *controller) createSpare(r *controllers.Ctx[*v2.FirewallDeployment]) (*v2.Firewall, error) {
+	// spares are created in a loop, time-based uuids would share their first characters
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		return nil, err
+	}
+
+	clusterName := r.Target.Namespace
+	name := fmt.Sprintf("%s-firewall-%s", clusterName, uuid.String()[:5])
+
+	// template labels are only added on adoption such that the spare is not matched by the selector of any set
+	meta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: r.Target.Namespace,
+		Labels: map[string]string{
+			v2.FirewallSpareLabel: r.Target.Name,
+		},
+	}
+
+	if v, err := semver.NewVersion(r.Target.Spec.Template.Spec.ControllerVersion); err == nil && v.LessThan(semver.MustParse("v2.0.0")) {
+		meta.Annotations = map[string]string{
+			v2.FirewallNoControllerConnectionAnnotation: "true",
+		}
+	}
+
+	if val, ok := r.Target.Annotations[v2.FirewallNoControllerConnectionAnnotation]; ok {
+		if meta.Annotations == nil {
+			meta.Annotations = map[string]string{}
+		}
+		meta.Annotations[v2.FirewallNoControllerConnectionAnnotation] = val
+	}
+
+	fw := &v2.Firewall{
+		ObjectMeta: meta,
+		Spec:       firewallTemplate(r.Target).Spec,
+		Distance:   v2.FirewallLongestDistance,
+	}
+
+	err = c.c.GetSeedClient().Create(r.Ctx, fw, &client.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create spare firewall: %w", err)
+	}
+
+	return fw, nil
+}
+
+// deleteSpares deletes all spare firewalls of the deployment.
+func (c *controller) deleteSpares(r *controllers.Ctx[*v2.FirewallDeployment]) error {
+	spares, err := c.getSpares(r)
+	if err != nil {
+		return err
+	}
+
+	for _, spare := range spares {
+		if spare.DeletionTimestamp != nil {
+			continue
+		}
+
+		err := c.c.GetSeedClient().Delete(r.Ctx, spare)
+		if client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("unable to delete spare firewall: %w", err)
+		}
+
+		c.recorder.Eventf(spare, nil, corev1.EventTypeNormal, "Delete", "deleting spare", "deleted spare firewall %s", spare.Name)
+	}
+
+	if len(spares) > 0 {
+		return controllers.RequeueAfter(2*time.Second, "spare firewalls are getting deleted, waiting")
+	}
+
+	return nil
+}
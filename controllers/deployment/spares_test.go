@@ -0,0 +1,141 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_controller_syncSpares(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	newSpare := func(name, image string, ready bool) *v2.Firewall {
+		fw := &v2.Firewall{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test",
+				Labels: map[string]string{
+					v2.FirewallSpareLabel: "fwdeploy",
+				},
+			},
+			Spec: v2.FirewallSpec{
+				Image: image,
+				Size:  "size-a",
+			},
+			Distance: v2.FirewallLongestDistance,
+		}
+
+		if ready {
+			fw.Status.Phase = v2.FirewallPhaseCreating
+			fw.Status.Conditions = v2.Conditions{
+				v2.NewCondition(v2.FirewallCreated, v2.ConditionTrue, "Created", ""),
+				v2.NewCondition(v2.FirewallReady, v2.ConditionTrue, "Ready", ""),
+				v2.NewCondition(v2.FirewallProvisioned, v2.ConditionTrue, "Provisioned", ""),
+			}
+		}
+
+		return fw
+	}
+
+	tests := []struct {
+		name      string
+		spares    int
+		existing  []*v2.Firewall
+		wantKept  []string
+		wantTotal int
+		wantReady int
+	}{
+		{
+			name:      "creates missing spares",
+			spares:    2,
+			existing:  nil,
+			wantTotal: 2,
+		},
+		{
+			name:      "replaces spares that do not match the template",
+			spares:    1,
+			existing:  []*v2.Firewall{newSpare("outdated", "a", true)},
+			wantTotal: 1,
+		},
+		{
+			name:      "keeps ready spares on scale down",
+			spares:    1,
+			existing:  []*v2.Firewall{newSpare("unready", "b", false), newSpare("ready", "b", true)},
+			wantKept:  []string{"ready"},
+			wantTotal: 1,
+			wantReady: 1,
+		},
+		{
+			name:      "removes all spares when disabled",
+			spares:    0,
+			existing:  []*v2.Firewall{newSpare("ready", "b", true)},
+			wantTotal: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy := &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fwdeploy",
+					Namespace: "test",
+					UID:       "deploy-uid",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Spares: tt.spares,
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Image: "b",
+							Size:  "size-a",
+						},
+					},
+				},
+			}
+
+			objs := []client.Object{deploy}
+			for _, fw := range tt.existing {
+				objs = append(objs, fw)
+			}
+
+			c := newTestController(t, scheme, objs...)
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy,
+			}
+
+			require.NoError(t, c.syncSpares(r))
+
+			assert.Equal(t, tt.wantTotal, r.Target.Status.SpareReplicas)
+			assert.Equal(t, tt.wantReady, r.Target.Status.ReadySpareReplicas)
+
+			spares, err := c.getSpares(r)
+			require.NoError(t, err)
+			require.Len(t, spares, tt.wantTotal)
+
+			var names []string
+			for _, spare := range spares {
+				names = append(names, spare.Name)
+
+				assert.Equal(t, "b", spare.Spec.Image)
+				assert.Equal(t, v2.FirewallLongestDistance, spare.Distance)
+				assert.Nil(t, metav1.GetControllerOf(spare))
+			}
+
+			for _, name := range tt.wantKept {
+				assert.Contains(t, names, name)
+			}
+		})
+	}
+}
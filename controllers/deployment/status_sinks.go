@@ -0,0 +1,264 @@
+package deployment
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const defaultConfigMapStatusSinkKey = "egressCIDRs"
+
+// statusSink publishes the egress cidrs of the firewalls of a firewall deployment.
+type statusSink interface {
+	// name returns the name of the sink for logging purposes.
+	name() string
+	// publish publishes the egress cidrs of the given firewalls.
+	publish(r *controllers.Ctx[*v2.FirewallDeployment], ownedFirewalls []*v2.Firewall) error
+}
+
+// statusSinks returns the sinks in which the egress cidrs of the deployment are published. the gardener infrastructure
+// is always updated when running in a shoot namespace of a gardener seed.
+func (c *controller) statusSinks(r *controllers.Ctx[*v2.FirewallDeployment]) []statusSink {
+	var sinks []statusSink
+
+	if infrastructureName, ok := extractInfrastructureNameFromSeedNamespace(c.c.GetSeedNamespace()); ok {
+		sinks = append(sinks, &infrastructureSink{c: c, infrastructureName: infrastructureName})
+	}
+
+	for _, s := range r.Target.Spec.StatusSinks {
+		switch {
+		case s.ConfigMap != nil:
+			sinks = append(sinks, &configMapSink{c: c, spec: s.ConfigMap})
+		case s.Deployment != nil:
+			sinks = append(sinks, &deploymentStatusSink{})
+		case s.Resource != nil:
+			sinks = append(sinks, &resourceSink{c: c, spec: s.Resource})
+		}
+	}
+
+	return sinks
+}
+
+func (c *controller) updateStatusSinks(r *controllers.Ctx[*v2.FirewallDeployment], ownedSets []*v2.FirewallSet) error {
+	if !slices.ContainsFunc(r.Target.Spec.StatusSinks, func(s v2.FirewallStatusSink) bool { return s.Deployment != nil }) {
+		r.Target.Status.EgressCIDRs = nil
+	}
+
+	sinks := c.statusSinks(r)
+	if len(sinks) == 0 {
+		return nil
+	}
+
+	var ownedFirewalls []*v2.Firewall
+	for _, set := range ownedSets {
+		fws, _, err := controllers.GetOwnedResources(r.Ctx, c.c.GetSeedClient(), nil, set, &v2.FirewallList{}, func(fl *v2.FirewallList) []*v2.Firewall {
+			return fl.GetItems()
+		})
+		if err != nil {
+			return fmt.Errorf("unable to get owned firewalls: %w", err)
+		}
+
+		ownedFirewalls = append(ownedFirewalls, fws...)
+	}
+
+	// a failing sink does not prevent the others from being updated
+	var errs []error
+	for _, sink := range sinks {
+		err := sink.publish(r, ownedFirewalls)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to publish egress cidrs to %s: %w", sink.name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// infrastructureSink patches the egress cidrs into the status of the gardener infrastructure.
+type infrastructureSink struct {
+	c                  *controller
+	infrastructureName string
+}
+
+func (s *infrastructureSink) name() string {
+	return "gardener infrastructure"
+}
+
+func (s *infrastructureSink) publish(r *controllers.Ctx[*v2.FirewallDeployment], ownedFirewalls []*v2.Firewall) error {
+	return s.c.updateInfrastructureStatus(r, s.infrastructureName, ownedFirewalls)
+}
+
+// configMapSink stores the egress cidrs as a comma-separated list in a config map.
+type configMapSink struct {
+	c    *controller
+	spec *v2.FirewallConfigMapStatusSink
+}
+
+func (s *configMapSink) name() string {
+	return fmt.Sprintf("config map %q", s.spec.Name)
+}
+
+func (s *configMapSink) publish(r *controllers.Ctx[*v2.FirewallDeployment], ownedFirewalls []*v2.Firewall) error {
+	key := s.spec.Key
+	if key == "" {
+		key = defaultConfigMapStatusSinkKey
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.spec.Name,
+			Namespace: r.Target.Namespace,
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(r.Ctx, s.c.c.GetSeedClient(), cm, func() error {
+		// config maps that were created by the user are not garbage collected with the deployment
+		if cm.CreationTimestamp.IsZero() {
+			cm.OwnerReferences = []metav1.OwnerReference{
+				*metav1.NewControllerRef(r.Target, v2.GroupVersion.WithKind("FirewallDeployment")),
+			}
+		}
+
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[key] = strings.Join(deploymentEgressCIDRs(r.Target, ownedFirewalls), ",")
+
+		return nil
+	})
+
+	return err
+}
+
+// deploymentStatusSink reports the egress cidrs in the status of the firewall deployment.
+type deploymentStatusSink struct{}
+
+func (s *deploymentStatusSink) name() string {
+	return "firewall deployment status"
+}
+
+func (s *deploymentStatusSink) publish(r *controllers.Ctx[*v2.FirewallDeployment], ownedFirewalls []*v2.Firewall) error {
+	r.Target.Status.EgressCIDRs = deploymentEgressCIDRs(r.Target, ownedFirewalls)
+	return nil
+}
+
+// resourceSink patches the egress cidrs into a field of an arbitrary resource.
+type resourceSink struct {
+	c    *controller
+	spec *v2.FirewallResourceStatusSink
+}
+
+func (s *resourceSink) name() string {
+	return fmt.Sprintf("%s %q", s.spec.Kind, s.spec.Name)
+}
+
+func (s *resourceSink) publish(r *controllers.Ctx[*v2.FirewallDeployment], ownedFirewalls []*v2.Firewall) error {
+	fields, err := s.spec.Fields()
+	if err != nil {
+		return err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(s.spec.APIVersion, s.spec.Kind))
+
+	err = s.c.c.GetSeedClient().Get(r.Ctx, client.ObjectKey{
+		Namespace: r.Target.Namespace,
+		Name:      s.spec.Name,
+	}, obj)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			r.Log.Info("resource of status sink not found, not publishing egress cidrs", "kind", s.spec.Kind, "name", s.spec.Name)
+			return nil
+		}
+		return err
+	}
+
+	egressCIDRs := deploymentEgressCIDRs(r.Target, ownedFirewalls)
+
+	current, _, err := unstructured.NestedStringSlice(obj.Object, fields...)
+	if err == nil && slices.Equal(current, egressCIDRs) {
+		return nil
+	}
+
+	patch := map[string]any{}
+	if err := unstructured.SetNestedStringSlice(patch, egressCIDRs, fields...); err != nil {
+		return err
+	}
+
+	jsonPatch, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("unable to marshal status sink patch: %w", err)
+	}
+
+	if fields[0] == "status" {
+		err = s.c.c.GetSeedClient().Status().Patch(r.Ctx, obj, client.RawPatch(types.MergePatchType, jsonPatch))
+	} else {
+		err = s.c.c.GetSeedClient().Patch(r.Ctx, obj, client.RawPatch(types.MergePatchType, jsonPatch))
+	}
+	if err != nil {
+		return fmt.Errorf("error patching egress cidrs field: %w", err)
+	}
+
+	r.Log.Info("patched egress cidrs into status sink resource", "kind", s.spec.Kind, "name", s.spec.Name, "egress-cidrs", egressCIDRs)
+
+	return nil
+}
+
+// deploymentEgressCIDRs returns the sorted egress cidrs of the given firewalls and the egress rules of the deployment.
+func deploymentEgressCIDRs(d *v2.FirewallDeployment, ownedFirewalls []*v2.Firewall) []string {
+	egressCIDRs := firewallEgressCIDRs(ownedFirewalls)
+
+	for _, rule := range firewallTemplate(d).Spec.EgressRules {
+		egressCIDRs = append(egressCIDRs, ipsToCIDRs(rule.IPs)...)
+	}
+
+	slices.Sort(egressCIDRs)
+
+	return slices.Compact(egressCIDRs)
+}
+
+// firewallEgressCIDRs returns the ips of the external networks of the given firewalls as cidrs.
+func firewallEgressCIDRs(fws []*v2.Firewall) []string {
+	var egressCIDRs []string
+
+	for _, fw := range fws {
+		for _, network := range fw.Status.FirewallNetworks {
+			if pointer.SafeDeref(network.NetworkType) != "external" {
+				continue
+			}
+
+			egressCIDRs = append(egressCIDRs, ipsToCIDRs(network.IPs)...)
+		}
+	}
+
+	return egressCIDRs
+}
+
+func ipsToCIDRs(ips []string) []string {
+	var cidrs []string
+
+	for _, ip := range ips {
+		parsed, err := netip.ParseAddr(ip)
+		if err != nil {
+			continue
+		}
+
+		cidrs = append(cidrs, fmt.Sprintf("%s/%d", ip, parsed.BitLen()))
+	}
+
+	return cidrs
+}
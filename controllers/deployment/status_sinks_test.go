@@ -0,0 +1,172 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/config"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_controller_updateStatusSinks(t *testing.T) {
+	ctx := context.Background()
+	log := testr.New(t)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	newCluster := func() *unstructured.Unstructured {
+		return &unstructured.Unstructured{
+			Object: map[string]any{
+				"apiVersion": "example.com/v1",
+				"kind":       "Cluster",
+				"metadata": map[string]any{
+					"name":      "cluster",
+					"namespace": "test",
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		sinks         []v2.FirewallStatusSink
+		statusCIDRs   []string
+		wantStatus    []string
+		wantConfigMap map[string]string
+		wantCluster   []string
+	}{
+		{
+			name:        "no sinks configured resets the deployment status",
+			statusCIDRs: []string{"1.1.1.1/32"},
+		},
+		{
+			name:       "deployment status",
+			sinks:      []v2.FirewallStatusSink{{Deployment: &v2.FirewallDeploymentStatusSink{}}},
+			wantStatus: []string{"1.1.1.1/32", "2.2.2.2/32"},
+		},
+		{
+			name:          "config map",
+			sinks:         []v2.FirewallStatusSink{{ConfigMap: &v2.FirewallConfigMapStatusSink{Name: "egress"}}},
+			wantConfigMap: map[string]string{"egressCIDRs": "1.1.1.1/32,2.2.2.2/32"},
+		},
+		{
+			name: "arbitrary resource",
+			sinks: []v2.FirewallStatusSink{{Resource: &v2.FirewallResourceStatusSink{
+				APIVersion: "example.com/v1",
+				Kind:       "Cluster",
+				Name:       "cluster",
+				JSONPath:   ".status.network.egressCIDRs",
+			}}},
+			wantCluster: []string{"1.1.1.1/32", "2.2.2.2/32"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy := &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fwdeploy",
+					Namespace: "test",
+					UID:       "deploy-uid",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					StatusSinks: tt.sinks,
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							EgressRules: []v2.EgressRuleSNAT{{NetworkID: "internet", IPs: []string{"2.2.2.2"}}},
+						},
+					},
+				},
+				Status: v2.FirewallDeploymentStatus{
+					EgressCIDRs: tt.statusCIDRs,
+				},
+			}
+
+			set := &v2.FirewallSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "set",
+					Namespace: "test",
+					UID:       "set-uid",
+					OwnerReferences: []metav1.OwnerReference{
+						*metav1.NewControllerRef(deploy, v2.GroupVersion.WithKind("FirewallDeployment")),
+					},
+				},
+			}
+
+			fw := &v2.Firewall{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fw",
+					Namespace: "test",
+					OwnerReferences: []metav1.OwnerReference{
+						*metav1.NewControllerRef(set, v2.GroupVersion.WithKind("FirewallSet")),
+					},
+				},
+				Status: v2.FirewallStatus{
+					FirewallNetworks: []v2.FirewallNetwork{
+						{NetworkType: new("external"), IPs: []string{"1.1.1.1"}},
+						{NetworkType: new("underlay"), IPs: []string{"10.8.0.4"}},
+					},
+				},
+			}
+
+			cluster := newCluster()
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(deploy, set, fw, cluster).WithStatusSubresource(cluster).Build()
+
+			cc, err := config.New(&config.NewControllerConfig{
+				SeedClient:     c,
+				SeedNamespace:  "test",
+				SkipValidation: true,
+			})
+			require.NoError(t, err)
+
+			ctrl := &controller{
+				log: log,
+				c:   cc,
+			}
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    log,
+				Target: deploy,
+			}
+
+			require.NoError(t, ctrl.updateStatusSinks(r, []*v2.FirewallSet{set}))
+
+			if diff := cmp.Diff(tt.wantStatus, r.Target.Status.EgressCIDRs); diff != "" {
+				t.Errorf("deployment status diff (+got -want):\n %s", diff)
+			}
+
+			if tt.wantConfigMap != nil {
+				cm := &corev1.ConfigMap{}
+				require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "test", Name: "egress"}, cm))
+
+				if diff := cmp.Diff(tt.wantConfigMap, cm.Data); diff != "" {
+					t.Errorf("config map diff (+got -want):\n %s", diff)
+				}
+				require.Len(t, cm.OwnerReferences, 1)
+			}
+
+			got := newCluster()
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(got), got))
+
+			cidrs, _, err := unstructured.NestedStringSlice(got.Object, "status", "network", "egressCIDRs")
+			require.NoError(t, err)
+
+			if diff := cmp.Diff(tt.wantCluster, cidrs); diff != "" {
+				t.Errorf("resource diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
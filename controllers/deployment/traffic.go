@@ -0,0 +1,153 @@
+package deployment
+
+import (
+	"fmt"
+	"time"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// shiftTraffic lowers the distance of the latest set step by step until the shortest distance is reached.
+// between the steps, it waits until the firewall-controllers have configured the distance of the set.
+// at the shortest distance, it verifies that the new firewalls receive traffic.
+//
+// returns nil when the traffic was shifted to the latest set entirely.
+func (c *controller) shiftTraffic(r *controllers.Ctx[*v2.FirewallDeployment], latestSet *v2.FirewallSet) error {
+	fws, _, err := controllers.GetOwnedResources(r.Ctx, c.c.GetSeedClient(), latestSet.Spec.Selector, latestSet, &v2.FirewallList{}, func(fl *v2.FirewallList) []*v2.Firewall {
+		return fl.GetItems()
+	})
+	if err != nil {
+		return fmt.Errorf("unable to get owned firewalls: %w", err)
+	}
+
+	if len(fws) == 0 {
+		return nil
+	}
+
+	for _, fw := range fws {
+		if !isDistanceConfigured(fw, latestSet.Spec.Distance, fws) {
+			r.Log.Info("waiting for firewall to configure distance", "firewall-name", fw.Name, "distance", latestSet.Spec.Distance)
+
+			cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionTrue, "ShiftingTraffic", fmt.Sprintf("Waiting for firewall %q to configure distance %d.", fw.Name, latestSet.Spec.Distance))
+			r.Target.Status.Conditions.Set(cond)
+
+			return controllers.RequeueAfter(10*time.Second, "waiting for firewalls to configure distance")
+		}
+	}
+
+	if latestSet.Spec.Distance > v2.FirewallShortestDistance {
+		latestSet.Spec.Distance--
+
+		err := c.c.GetSeedClient().Update(r.Ctx, latestSet)
+		if err != nil {
+			return fmt.Errorf("unable to lower latest set distance to %d: %w", latestSet.Spec.Distance, err)
+		}
+
+		r.Log.Info("lowered latest set distance", "distance", latestSet.Spec.Distance)
+
+		cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionTrue, "ShiftingTraffic", fmt.Sprintf("Lowered distance of FirewallSet %q to %d.", latestSet.Name, latestSet.Spec.Distance))
+		r.Target.Status.Conditions.Set(cond)
+
+		c.recorder.Eventf(latestSet, nil, corev1.EventTypeNormal, "ShiftTraffic", "shifting traffic", "lowered distance of firewall set %s to %d", latestSet.Name, latestSet.Spec.Distance)
+
+		return controllers.RequeueAfter(10*time.Second, "shifting traffic to latest firewall set")
+	}
+
+	bytes, reported, err := c.trafficBytes(r, fws)
+	if err != nil {
+		return err
+	}
+
+	if !reported {
+		r.Log.Info("firewall-controllers do not report device statistics, cannot verify traffic on the latest firewall set")
+
+		c.recorder.Eventf(latestSet, nil, corev1.EventTypeWarning, "ShiftTraffic", "verifying traffic", "unable to verify traffic on firewall set %s as no device statistics are reported", latestSet.Name)
+
+		return nil
+	}
+
+	snapshot, ok := c.trafficSnapshots[latestSet.UID]
+	if !ok || bytes <= snapshot {
+		c.trafficSnapshots[latestSet.UID] = bytes
+
+		r.Log.Info("waiting for traffic on latest firewall set", "bytes", bytes)
+
+		cond := v2.NewCondition(v2.FirewallDeploymentProgressing, v2.ConditionTrue, "ShiftingTraffic", fmt.Sprintf("Waiting for traffic on FirewallSet %q.", latestSet.Name))
+		r.Target.Status.Conditions.Set(cond)
+
+		return controllers.RequeueAfter(30*time.Second, "waiting for traffic on latest firewall set")
+	}
+
+	delete(c.trafficSnapshots, latestSet.UID)
+
+	r.Log.Info("verified traffic on latest firewall set", "bytes", bytes)
+
+	return nil
+}
+
+// trafficBytes returns the sum of the transferred bytes of all devices of the given firewalls as reported by the firewall monitors.
+func (c *controller) trafficBytes(r *controllers.Ctx[*v2.FirewallDeployment], fws []*v2.Firewall) (uint64, bool, error) {
+	var (
+		bytes    uint64
+		reported bool
+	)
+
+	for _, fw := range fws {
+		mon := &v2.FirewallMonitor{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fw.Name,
+				Namespace: c.c.GetShootNamespace(),
+			},
+		}
+
+		err := c.c.GetShootClient().Get(r.Ctx, client.ObjectKeyFromObject(mon), mon)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return 0, false, fmt.Errorf("unable to get firewall monitor: %w", err)
+		}
+
+		if mon.ControllerStatus == nil || mon.ControllerStatus.FirewallStats == nil {
+			continue
+		}
+
+		for _, stat := range mon.ControllerStatus.FirewallStats.DeviceStats {
+			bytes += stat.InBytes + stat.OutBytes
+			reported = true
+		}
+	}
+
+	return bytes, reported, nil
+}
+
+// isDistanceConfigured returns true if the distance of the set was propagated to the given firewall and the firewall-controller
+// has configured the firewall's distance.
+func isDistanceConfigured(fw *v2.Firewall, setDistance v2.FirewallDistance, fws []*v2.Firewall) bool {
+	// the set controller assigns the set distance to the most important firewall, the other firewalls get longer distances
+	minDistance := fw.Distance
+	for _, f := range fws {
+		minDistance = min(minDistance, f.Distance)
+	}
+
+	if minDistance != setDistance {
+		return false
+	}
+
+	cond := fw.Status.Conditions.Get(v2.FirewallDistanceConfigured)
+	if cond == nil || cond.Status != v2.ConditionTrue {
+		return false
+	}
+
+	if cond.Reason == "NotChecking" || fw.Status.ControllerStatus == nil {
+		return true
+	}
+
+	return fw.Status.ControllerStatus.ActualDistance == fw.Distance
+}
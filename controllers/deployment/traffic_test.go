@@ -0,0 +1,197 @@
+package deployment
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func Test_controller_shiftTraffic(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	deploy := &v2.FirewallDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fwdeploy",
+			Namespace: "test",
+			UID:       "deploy-uid",
+		},
+	}
+
+	newFirewall := func(set *v2.FirewallSet, distance, actualDistance v2.FirewallDistance, configured bool) *v2.Firewall {
+		status := v2.ConditionFalse
+		if configured {
+			status = v2.ConditionTrue
+		}
+
+		return &v2.Firewall{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fw",
+				Namespace: "test",
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(set, v2.GroupVersion.WithKind("FirewallSet")),
+				},
+			},
+			Distance: distance,
+			Status: v2.FirewallStatus{
+				ControllerStatus: &v2.ControllerConnection{
+					ActualDistance: actualDistance,
+				},
+				Conditions: v2.Conditions{
+					v2.NewCondition(v2.FirewallDistanceConfigured, status, "Configured", ""),
+				},
+			},
+		}
+	}
+
+	newMonitor := func(bytes uint64) *v2.FirewallMonitor {
+		return &v2.FirewallMonitor{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fw",
+				Namespace: "firewall",
+			},
+			ControllerStatus: &v2.ControllerStatus{
+				FirewallStats: &v2.FirewallStats{
+					DeviceStats: v2.DeviceStatsByDevice{
+						"vlan1": {InBytes: bytes, OutBytes: bytes},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		setDistance  v2.FirewallDistance
+		firewall     func(set *v2.FirewallSet) *v2.Firewall
+		monitor      *v2.FirewallMonitor
+		snapshot     *uint64
+		wantRequeue  bool
+		wantDistance v2.FirewallDistance
+		wantSnapshot *uint64
+	}{
+		{
+			name:        "waiting for distance to be configured",
+			setDistance: v2.FirewallRollingUpdateSetDistance,
+			firewall: func(set *v2.FirewallSet) *v2.Firewall {
+				return newFirewall(set, 3, 4, false)
+			},
+			wantRequeue:  true,
+			wantDistance: v2.FirewallRollingUpdateSetDistance,
+		},
+		{
+			name:        "waiting for set distance to be propagated to the firewall",
+			setDistance: 2,
+			firewall: func(set *v2.FirewallSet) *v2.Firewall {
+				return newFirewall(set, 3, 3, true)
+			},
+			wantRequeue:  true,
+			wantDistance: 2,
+		},
+		{
+			name:        "lowers distance when configured",
+			setDistance: v2.FirewallRollingUpdateSetDistance,
+			firewall: func(set *v2.FirewallSet) *v2.Firewall {
+				return newFirewall(set, 3, 3, true)
+			},
+			wantRequeue:  true,
+			wantDistance: 2,
+		},
+		{
+			name:        "records traffic at shortest distance",
+			setDistance: v2.FirewallShortestDistance,
+			firewall: func(set *v2.FirewallSet) *v2.Firewall {
+				return newFirewall(set, 0, 0, true)
+			},
+			monitor:      newMonitor(100),
+			wantRequeue:  true,
+			wantDistance: v2.FirewallShortestDistance,
+			wantSnapshot: new(uint64(200)),
+		},
+		{
+			name:        "waiting for traffic at shortest distance",
+			setDistance: v2.FirewallShortestDistance,
+			firewall: func(set *v2.FirewallSet) *v2.Firewall {
+				return newFirewall(set, 0, 0, true)
+			},
+			monitor:      newMonitor(100),
+			snapshot:     new(uint64(200)),
+			wantRequeue:  true,
+			wantDistance: v2.FirewallShortestDistance,
+			wantSnapshot: new(uint64(200)),
+		},
+		{
+			name:        "traffic verified at shortest distance",
+			setDistance: v2.FirewallShortestDistance,
+			firewall: func(set *v2.FirewallSet) *v2.Firewall {
+				return newFirewall(set, 0, 0, true)
+			},
+			monitor:      newMonitor(200),
+			snapshot:     new(uint64(200)),
+			wantRequeue:  false,
+			wantDistance: v2.FirewallShortestDistance,
+		},
+		{
+			name:        "traffic cannot be verified without device statistics",
+			setDistance: v2.FirewallShortestDistance,
+			firewall: func(set *v2.FirewallSet) *v2.Firewall {
+				return newFirewall(set, 0, 0, true)
+			},
+			wantRequeue:  false,
+			wantDistance: v2.FirewallShortestDistance,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := newHistoryTestSet(deploy, 1, "b", 1)
+			set.Spec.Distance = tt.setDistance
+
+			objs := []client.Object{set, tt.firewall(set)}
+			if tt.monitor != nil {
+				objs = append(objs, tt.monitor)
+			}
+
+			c := newTestController(t, scheme, objs...)
+			if tt.snapshot != nil {
+				c.trafficSnapshots[set.UID] = *tt.snapshot
+			}
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: deploy.DeepCopy(),
+			}
+
+			err := c.shiftTraffic(r, set)
+			if tt.wantRequeue {
+				require.Error(t, err)
+				assert.True(t, isRequeue(err), "expected requeue, got: %s", err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			refetched := &v2.FirewallSet{}
+			require.NoError(t, c.c.GetSeedClient().Get(ctx, types.NamespacedName{Namespace: "test", Name: set.Name}, refetched))
+			assert.Equal(t, tt.wantDistance, refetched.Spec.Distance)
+
+			snapshot, ok := c.trafficSnapshots[set.UID]
+			if tt.wantSnapshot != nil {
+				require.True(t, ok)
+				assert.Equal(t, *tt.wantSnapshot, snapshot)
+			} else {
+				assert.False(t, ok)
+			}
+		})
+	}
+}
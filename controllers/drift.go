@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/metal-go/api/models"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// MachineDrift returns the differences between the machine of a firewall in the metal-api and the given firewall spec
+// regarding the properties that can only be changed by allocating a new machine.
+func MachineDrift(spec *v2.FirewallSpec, f *models.V1FirewallResponse) []string {
+	var (
+		diffs      []string
+		allocation = pointer.SafeDeref(f.Allocation)
+		// firewalls may have been allocated with a fallback
+		sizes      = append([]string{spec.Size}, spec.FallbackSizes...)
+		partitions = append([]string{spec.Partition}, spec.FallbackPartitions...)
+	)
+
+	if size := pointer.SafeDeref(pointer.SafeDeref(f.Size).ID); !slices.Contains(sizes, size) {
+		diffs = append(diffs, fmt.Sprintf("size %q (spec: %s)", size, strings.Join(sizes, ", ")))
+	}
+
+	if partition := pointer.SafeDeref(pointer.SafeDeref(f.Partition).ID); !slices.Contains(partitions, partition) {
+		diffs = append(diffs, fmt.Sprintf("partition %q (spec: %s)", partition, strings.Join(partitions, ", ")))
+	}
+
+	// the image of the spec can be a shorthand version, which is resolved to the latest patch version by the metal-api
+	if image := pointer.SafeDeref(pointer.SafeDeref(allocation.Image).ID); image != spec.Image && !strings.HasPrefix(image, spec.Image+".") {
+		diffs = append(diffs, fmt.Sprintf("image %q (spec: %s)", image, spec.Image))
+	}
+
+	if project := pointer.SafeDeref(allocation.Project); project != spec.Project {
+		diffs = append(diffs, fmt.Sprintf("project %q (spec: %s)", project, spec.Project))
+	}
+
+	var networks []string
+	for _, n := range allocation.Networks {
+		networks = append(networks, pointer.SafeDeref(n.Networkid))
+	}
+	if !sets.New(networks...).Equal(sets.New(spec.Networks...)) {
+		slices.Sort(networks)
+		diffs = append(diffs, fmt.Sprintf("networks %q (spec: %s)", strings.Join(networks, ", "), strings.Join(spec.Networks, ", ")))
+	}
+
+	return diffs
+}
@@ -0,0 +1,98 @@
+package firewall
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/metal-stack/metal-go/api/models"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkDrift compares the machine of the firewall in the metal-api with the firewall spec and reports differences in the
+// drifted condition of the firewall. if configured in the firewall set, a drift is remediated by rolling the set.
+func (c *controller) checkDrift(r *controllers.Ctx[*v2.Firewall], f *models.V1FirewallResponse) error {
+	var (
+		name  = pointer.SafeDeref(pointer.SafeDeref(f.Allocation).Name)
+		diffs = c.drift(r.Target, f)
+	)
+
+	if len(diffs) == 0 {
+		cond := v2.NewCondition(v2.FirewallDrifted, v2.ConditionFalse, "NoDrift", fmt.Sprintf("Firewall %q matches the firewall spec.", name))
+		r.Target.Status.Conditions.Set(cond)
+		return nil
+	}
+
+	if old := r.Target.Status.Conditions.Get(v2.FirewallDrifted); old == nil || old.Status != v2.ConditionTrue {
+		c.recorder.Eventf(r.Target, nil, corev1.EventTypeWarning, "Drifted", "detecting drift", "firewall %s has drifted from its spec: %s", name, strings.Join(diffs, "; "))
+	}
+
+	cond := v2.NewCondition(v2.FirewallDrifted, v2.ConditionTrue, "Drifted", fmt.Sprintf("Firewall %q has drifted from the firewall spec: %s.", name, strings.Join(diffs, "; ")))
+	r.Target.Status.Conditions.Set(cond)
+
+	r.Log.Info("firewall has drifted from its spec", "drift", diffs)
+
+	return c.remediateDrift(r)
+}
+
+// drift returns the field-level differences between the machine of the firewall in the metal-api and the firewall spec.
+// tags and ssh keys are synced before, such that they only drift if they cannot be reconciled.
+func (c *controller) drift(fw *v2.Firewall, f *models.V1FirewallResponse) []string {
+	var (
+		diffs      = controllers.MachineDrift(&fw.Spec, f)
+		spec       = &fw.Spec
+		allocation = pointer.SafeDeref(f.Allocation)
+	)
+
+	requiredTags := []string{v2.FirewallManagedByTag()}
+	if tag := c.c.GetClusterTag(); tag != "" {
+		requiredTags = append(requiredTags, tag)
+	}
+	if ref := metav1.GetControllerOf(fw); ref != nil {
+		requiredTags = append(requiredTags, v2.FirewallSetTag(ref.Name))
+	}
+	if missing := sets.List(sets.New(requiredTags...).Difference(sets.New(f.Tags...))); len(missing) > 0 {
+		diffs = append(diffs, fmt.Sprintf("missing tags %q", strings.Join(missing, ", ")))
+	}
+
+	if !slices.Equal(allocation.SSHPubKeys, spec.SSHPublicKeys) {
+		diffs = append(diffs, "ssh public keys")
+	}
+
+	return diffs
+}
+
+// remediateDrift triggers a roll of the firewall set of a drifted firewall if configured in the set.
+func (c *controller) remediateDrift(r *controllers.Ctx[*v2.Firewall]) error {
+	ref := metav1.GetControllerOf(r.Target)
+	if ref == nil || ref.Kind != "FirewallSet" {
+		return nil
+	}
+
+	set := &v2.FirewallSet{}
+	err := c.c.GetSeedClient().Get(r.Ctx, client.ObjectKey{Namespace: r.Target.Namespace, Name: ref.Name}, set)
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if set.Spec.DriftRemediation != v2.DriftRemediationRollSet || set.DeletionTimestamp != nil || v2.IsAnnotationTrue(set, v2.RollSetAnnotation) {
+		return nil
+	}
+
+	err = v2.AddAnnotation(r.Ctx, c.c.GetSeedClient(), set, v2.RollSetAnnotation, strconv.FormatBool(true))
+	if err != nil {
+		return fmt.Errorf("unable to annotate firewall set: %w", err)
+	}
+
+	r.Log.Info("initiated firewall set roll because of drift", "set-name", set.Name)
+	c.recorder.Eventf(set, nil, corev1.EventTypeNormal, "Roll", "remediating drift", "rolling firewall set %s because firewall %s has drifted from its spec", set.Name, r.Target.Name)
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package firewall
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/google/go-cmp/cmp"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/config"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/metal-stack/metal-go/api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_controller_checkDrift(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	machine := func(mutateFn func(f *models.V1FirewallResponse)) *models.V1FirewallResponse {
+		f := &models.V1FirewallResponse{
+			Size:      &models.V1SizeResponse{ID: new("size-b")},
+			Partition: &models.V1PartitionResponse{ID: new("partition-a")},
+			Tags:      []string{"cluster-tag", v2.FirewallManagedByTag(), v2.FirewallSetTag("set")},
+			Allocation: &models.V1MachineAllocation{
+				Name:       new("fw"),
+				Image:      &models.V1ImageResponse{ID: new("firewall-ubuntu-3.0.20240101")},
+				Project:    new("project-a"),
+				SSHPubKeys: []string{"ssh-key"},
+				Networks: []*models.V1MachineNetwork{
+					{Networkid: new("internet")},
+					{Networkid: new("private")},
+				},
+			},
+		}
+		if mutateFn != nil {
+			mutateFn(f)
+		}
+		return f
+	}
+
+	tests := []struct {
+		name        string
+		remediation v2.FirewallDriftRemediation
+		machine     *models.V1FirewallResponse
+		wantStatus  v2.ConditionStatus
+		wantMessage string
+		wantRoll    bool
+	}{
+		{
+			name:        "no drift with fallback size and shorthand image",
+			machine:     machine(nil),
+			wantStatus:  v2.ConditionFalse,
+			wantMessage: `Firewall "fw" matches the firewall spec.`,
+		},
+		{
+			name: "drift is reported without remediation",
+			machine: machine(func(f *models.V1FirewallResponse) {
+				f.Size.ID = new("size-c")
+				f.Allocation.Image.ID = new("firewall-ubuntu-2.0.20230101")
+				f.Tags = f.Tags[1:]
+			}),
+			wantStatus:  v2.ConditionTrue,
+			wantMessage: `Firewall "fw" has drifted from the firewall spec: size "size-c" (spec: size-a, size-b); image "firewall-ubuntu-2.0.20230101" (spec: firewall-ubuntu-3.0); missing tags "cluster-tag".`,
+		},
+		{
+			name:        "drift is remediated by rolling the set",
+			remediation: v2.DriftRemediationRollSet,
+			machine: machine(func(f *models.V1FirewallResponse) {
+				f.Allocation.Networks = f.Allocation.Networks[:1]
+			}),
+			wantStatus:  v2.ConditionTrue,
+			wantMessage: `Firewall "fw" has drifted from the firewall spec: networks "internet" (spec: internet, private).`,
+			wantRoll:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := &v2.FirewallSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "set",
+					Namespace: "test",
+					UID:       "set-uid",
+				},
+				Spec: v2.FirewallSetSpec{
+					DriftRemediation: tt.remediation,
+				},
+			}
+
+			fw := &v2.Firewall{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "fw",
+					Namespace:       "test",
+					OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(set, v2.GroupVersion.WithKind("FirewallSet"))},
+				},
+				Spec: v2.FirewallSpec{
+					Size:          "size-a",
+					FallbackSizes: []string{"size-b"},
+					Partition:     "partition-a",
+					Image:         "firewall-ubuntu-3.0",
+					Project:       "project-a",
+					Networks:      []string{"internet", "private"},
+					SSHPublicKeys: []string{"ssh-key"},
+				},
+			}
+
+			seed := fake.NewClientBuilder().WithScheme(scheme).WithObjects(set, fw).Build()
+
+			cc, err := config.New(&config.NewControllerConfig{
+				SeedClient:     seed,
+				ClusterTag:     "cluster-tag",
+				SkipValidation: true,
+			})
+			require.NoError(t, err)
+
+			c := &controller{
+				c:        cc,
+				recorder: events.NewFakeRecorder(10),
+			}
+
+			r := &controllers.Ctx[*v2.Firewall]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: fw,
+			}
+
+			require.NoError(t, c.checkDrift(r, tt.machine))
+
+			cond := r.Target.Status.Conditions.Get(v2.FirewallDrifted)
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantStatus, cond.Status)
+
+			if diff := cmp.Diff(tt.wantMessage, cond.Message); diff != "" {
+				t.Errorf("message diff (+got -want):\n %s", diff)
+			}
+
+			refetched := &v2.FirewallSet{}
+			require.NoError(t, seed.Get(ctx, client.ObjectKeyFromObject(set), refetched))
+			assert.Equal(t, tt.wantRoll, v2.IsAnnotationTrue(refetched, v2.RollSetAnnotation))
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package firewall
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"slices"
 	"strings"
 	"time"
@@ -48,6 +50,25 @@ func (c *controller) Reconcile(r *controllers.Ctx[*v2.Firewall]) error {
 		return controllers.RequeueAfter(0*time.Second, "removed systemd service restart annotation, requeue for regular reconcile")
 	}
 
+	if controllers.IsQuarantined(r.Target) {
+		// an invalid annotation would keep the machine allocated forever, so the quarantine is treated as expired then
+		until, err := controllers.QuarantinedUntil(r.Target)
+		if err != nil {
+			r.Log.Error(err, "unable to determine end of quarantine, treating quarantine as expired")
+		}
+
+		if err != nil || time.Now().After(until) {
+			err := c.c.GetSeedClient().Delete(r.Ctx, r.Target)
+			if err != nil {
+				return fmt.Errorf("unable to delete quarantined firewall: %w", err)
+			}
+
+			c.recorder.Eventf(r.Target, nil, corev1.EventTypeNormal, "Delete", "deleting firewall", "deleted firewall %s because its quarantine has expired", r.Target.Name)
+
+			return nil
+		}
+	}
+
 	var f *models.V1FirewallResponse
 	defer func() {
 		if err := c.setStatus(r, f); err != nil {
@@ -115,6 +136,12 @@ func (c *controller) Reconcile(r *controllers.Ctx[*v2.Firewall]) error {
 				return controllers.RequeueAfter(10*time.Second, "error syncing firewall ssh keys, backing off")
 			}
 
+			err = c.checkDrift(r, f)
+			if err != nil {
+				r.Log.Error(err, "error remediating firewall drift")
+				return controllers.RequeueAfter(10*time.Second, "error remediating firewall drift, backing off")
+			}
+
 			// to make the controller always sync the status with the metal-api, we requeue
 			return controllers.RequeueAfter(2*time.Minute, "firewall is running, continue probing regularly for status sync")
 
@@ -175,22 +202,45 @@ func (c *controller) createFirewall(r *controllers.Ctx[*v2.Firewall]) (*models.V
 		tags = append(tags, v2.FirewallSetTag(ref.Name))
 	}
 
-	createRequest := &models.V1FirewallCreateRequest{
-		Description: "created by firewall-controller-manager",
-		Name:        r.Target.Name,
-		Hostname:    r.Target.Name,
-		Sizeid:      &r.Target.Spec.Size,
-		Projectid:   &r.Target.Spec.Project,
-		Partitionid: &r.Target.Spec.Partition,
-		Imageid:     &r.Target.Spec.Image,
-		SSHPubKeys:  r.Target.Spec.SSHPublicKeys,
-		Networks:    networks,
-		UserData:    r.Target.Spec.Userdata,
-		Tags:        tags,
+	placementTags, err := c.placementTags(r)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := c.c.GetMetal().Firewall().AllocateFirewall(firewall.NewAllocateFirewallParams().WithBody(createRequest).WithContext(r.Ctx), nil)
-	if err != nil {
+	var (
+		resp       *firewall.AllocateFirewallOK
+		placements = allocationPlacements(&r.Target.Spec)
+		used       placement
+	)
+
+	for i, p := range placements {
+		createRequest := &models.V1FirewallCreateRequest{
+			Description:   "created by firewall-controller-manager",
+			Name:          r.Target.Name,
+			Hostname:      r.Target.Name,
+			Sizeid:        &p.size,
+			Projectid:     &r.Target.Spec.Project,
+			Partitionid:   &p.partition,
+			Imageid:       &r.Target.Spec.Image,
+			SSHPubKeys:    r.Target.Spec.SSHPublicKeys,
+			Networks:      networks,
+			UserData:      r.Target.Spec.Userdata,
+			Tags:          tags,
+			PlacementTags: placementTags,
+		}
+
+		resp, err = c.c.GetMetal().Firewall().AllocateFirewall(firewall.NewAllocateFirewallParams().WithBody(createRequest).WithContext(r.Ctx), nil)
+		if err == nil {
+			used = p
+			break
+		}
+
+		if isNoMachineAvailable(err) && i < len(placements)-1 {
+			next := placements[i+1]
+			r.Log.Info("no machine available, trying next fallback", "size", p.size, "partition", p.partition, "next-size", next.size, "next-partition", next.partition)
+			continue
+		}
+
 		r.Log.Error(err, "error creating firewall")
 
 		cond := v2.NewCondition(v2.FirewallCreated, v2.ConditionFalse, "NotCreated", fmt.Sprintf("Firewall could not be created: %s.", err))
@@ -202,6 +252,9 @@ func (c *controller) createFirewall(r *controllers.Ctx[*v2.Firewall]) (*models.V
 	r.Log.Info("firewall created", "id", pointer.SafeDeref(resp.Payload.ID))
 
 	cond := v2.NewCondition(v2.FirewallCreated, v2.ConditionTrue, "Created", fmt.Sprintf("Firewall %q created successfully.", pointer.SafeDeref(pointer.SafeDeref(resp.Payload.Allocation).Name)))
+	if used != placements[0] {
+		cond = v2.NewCondition(v2.FirewallCreated, v2.ConditionTrue, "CreatedWithFallback", fmt.Sprintf("Firewall %q created successfully with fallback size %q in partition %q.", pointer.SafeDeref(pointer.SafeDeref(resp.Payload.Allocation).Name), used.size, used.partition))
+	}
 	r.Target.Status.Conditions.Set(cond)
 
 	c.recorder.Eventf(r.Target, nil, corev1.EventTypeNormal, "Create", "created firewall %s id %s", r.Target.Name, pointer.SafeDeref(resp.Payload.ID))
@@ -209,6 +262,79 @@ func (c *controller) createFirewall(r *controllers.Ctx[*v2.Firewall]) (*models.V
 	return resp.Payload, nil
 }
 
+// placementTags returns the placement tags for the allocation of a firewall. if the firewall has replicas, the metal-api
+// places it in another rack than the other firewalls of the cluster, which all carry the cluster tag, such that the
+// replicas do not share the same failure domain.
+//
+// spares are spread as well if the deployment they belong to has replicas.
+func (c *controller) placementTags(r *controllers.Ctx[*v2.Firewall]) ([]string, error) {
+	var replicas int
+
+	if ref := metav1.GetControllerOf(r.Target); ref != nil && ref.Kind == "FirewallSet" {
+		set := &v2.FirewallSet{}
+		err := c.c.GetSeedClient().Get(r.Ctx, client.ObjectKey{Namespace: r.Target.Namespace, Name: ref.Name}, set)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("unable to get firewall set: %w", err)
+		}
+
+		replicas = set.Spec.Replicas
+	} else if name, ok := r.Target.Labels[v2.FirewallSpareLabel]; ok {
+		deploy := &v2.FirewallDeployment{}
+		err := c.c.GetSeedClient().Get(r.Ctx, client.ObjectKey{Namespace: r.Target.Namespace, Name: name}, deploy)
+		if client.IgnoreNotFound(err) != nil {
+			return nil, fmt.Errorf("unable to get firewall deployment: %w", err)
+		}
+
+		replicas = deploy.Spec.Replicas
+	}
+
+	if replicas <= 1 {
+		return nil, nil
+	}
+
+	return []string{c.c.GetClusterTag()}, nil
+}
+
+// placement is a combination of machine size and partition a firewall can be allocated in.
+type placement struct {
+	size      string
+	partition string
+}
+
+// allocationPlacements returns the placements for the allocation of a firewall in the order they should be tried.
+// the fallback sizes are tried within a partition before falling back to the next partition.
+func allocationPlacements(spec *v2.FirewallSpec) []placement {
+	var (
+		result     []placement
+		sizes      = append([]string{spec.Size}, spec.FallbackSizes...)
+		partitions = append([]string{spec.Partition}, spec.FallbackPartitions...)
+	)
+
+	for _, partition := range partitions {
+		for _, size := range sizes {
+			result = append(result, placement{size: size, partition: partition})
+		}
+	}
+
+	return result
+}
+
+// noMachineAvailableMessage is the error message of the metal-api when a machine allocation fails because there is no
+// free machine of the requested size in the requested partition. the metal-api responds with status code 422 in this
+// case, which it also uses for other allocation errors, so the message is the only way to tell these errors apart.
+const noMachineAvailableMessage = "no machine available"
+
+// isNoMachineAvailable returns true if the allocation failed because there is no free machine
+// of the requested size in the requested partition.
+func isNoMachineAvailable(err error) bool {
+	var defaultErr *firewall.AllocateFirewallDefault
+	if !errors.As(err, &defaultErr) || !defaultErr.IsCode(http.StatusUnprocessableEntity) || defaultErr.Payload == nil {
+		return false
+	}
+
+	return strings.Contains(defaultErr.Payload.Message, noMachineAvailableMessage)
+}
+
 func isFirewallProgressing(status *v2.MachineStatus) bool {
 	if status == nil || status.LastEvent == nil {
 		return false
@@ -270,6 +396,8 @@ func (c *controller) syncTags(r *controllers.Ctx[*v2.Firewall], m *models.V1Fire
 		return err
 	}
 
+	m.Tags = newTags
+
 	return nil
 }
 
@@ -320,6 +448,8 @@ func (c *controller) syncSSHPubKey(r *controllers.Ctx[*v2.Firewall], m *models.V
 		return fmt.Errorf("unable to update ssh public keys: %w", err)
 	}
 
+	m.Allocation.SSHPubKeys = r.Target.Spec.SSHPublicKeys
+
 	r.Log.Info("updated changed ssh public keys")
 
 	return nil
@@ -1,9 +1,31 @@
 package firewall
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/go-logr/logr/testr"
 	"github.com/google/go-cmp/cmp"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/config"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	metalgo "github.com/metal-stack/metal-go"
+	"github.com/metal-stack/metal-go/api/client/firewall"
+	"github.com/metal-stack/metal-go/api/models"
+	metaltestclient "github.com/metal-stack/metal-go/test/client"
+	"github.com/metal-stack/metal-lib/httperrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func Test_ensureTag(t *testing.T) {
@@ -61,3 +83,284 @@ func Test_ensureTag(t *testing.T) {
 		})
 	}
 }
+
+func Test_controller_createFirewall(t *testing.T) {
+	ctx := context.Background()
+
+	noMachineAvailable := firewall.NewAllocateFirewallDefault(http.StatusUnprocessableEntity)
+	noMachineAvailable.Payload = &httperrors.HTTPErrorResponse{
+		StatusCode: http.StatusUnprocessableEntity,
+		Message:    "no machine available",
+	}
+
+	placementOf := func(size, partition string) any {
+		return mock.MatchedBy(func(p *firewall.AllocateFirewallParams) bool {
+			return *p.Body.Sizeid == size && *p.Body.Partitionid == partition
+		})
+	}
+
+	created := &firewall.AllocateFirewallOK{
+		Payload: &models.V1FirewallResponse{
+			ID: new("machine-id"),
+			Allocation: &models.V1MachineAllocation{
+				Name: new("fw"),
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		spec       v2.FirewallSpec
+		metalMocks *metaltestclient.MetalMockFns
+		wantErr    bool
+		wantReason string
+	}{
+		{
+			name: "created with requested size",
+			spec: v2.FirewallSpec{
+				Size:          "size-a",
+				FallbackSizes: []string{"size-b"},
+				Partition:     "partition-a",
+			},
+			metalMocks: &metaltestclient.MetalMockFns{
+				Firewall: func(m *mock.Mock) {
+					m.On("AllocateFirewall", placementOf("size-a", "partition-a"), nil).Return(created, nil).Once()
+				},
+			},
+			wantReason: "Created",
+		},
+		{
+			name: "falls back to next size and partition",
+			spec: v2.FirewallSpec{
+				Size:               "size-a",
+				FallbackSizes:      []string{"size-b"},
+				Partition:          "partition-a",
+				FallbackPartitions: []string{"partition-b"},
+			},
+			metalMocks: &metaltestclient.MetalMockFns{
+				Firewall: func(m *mock.Mock) {
+					m.On("AllocateFirewall", placementOf("size-a", "partition-a"), nil).Return(nil, noMachineAvailable).Once()
+					m.On("AllocateFirewall", placementOf("size-b", "partition-a"), nil).Return(nil, noMachineAvailable).Once()
+					m.On("AllocateFirewall", placementOf("size-a", "partition-b"), nil).Return(created, nil).Once()
+				},
+			},
+			wantReason: "CreatedWithFallback",
+		},
+		{
+			name: "does not fall back on other errors",
+			spec: v2.FirewallSpec{
+				Size:          "size-a",
+				FallbackSizes: []string{"size-b"},
+				Partition:     "partition-a",
+			},
+			metalMocks: &metaltestclient.MetalMockFns{
+				Firewall: func(m *mock.Mock) {
+					m.On("AllocateFirewall", placementOf("size-a", "partition-a"), nil).Return(nil, errors.New("internal server error")).Once()
+				},
+			},
+			wantErr:    true,
+			wantReason: "NotCreated",
+		},
+		{
+			name: "fails when all fallbacks are exhausted",
+			spec: v2.FirewallSpec{
+				Size:          "size-a",
+				FallbackSizes: []string{"size-b"},
+				Partition:     "partition-a",
+			},
+			metalMocks: &metaltestclient.MetalMockFns{
+				Firewall: func(m *mock.Mock) {
+					m.On("AllocateFirewall", placementOf("size-a", "partition-a"), nil).Return(nil, noMachineAvailable).Once()
+					m.On("AllocateFirewall", placementOf("size-b", "partition-a"), nil).Return(nil, noMachineAvailable).Once()
+				},
+			},
+			wantErr:    true,
+			wantReason: "NotCreated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mc := metaltestclient.NewMetalMockClient(t, tt.metalMocks)
+
+			cc, err := config.New(&config.NewControllerConfig{
+				Metal:          mc,
+				ClusterTag:     "cluster-tag",
+				SkipValidation: true,
+			})
+			require.NoError(t, err)
+
+			c := &controller{
+				c:        cc,
+				recorder: events.NewFakeRecorder(10),
+			}
+
+			r := &controllers.Ctx[*v2.Firewall]{
+				Ctx: ctx,
+				Log: testr.New(t),
+				Target: &v2.Firewall{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "fw",
+						Namespace: "test",
+					},
+					Spec: tt.spec,
+				},
+			}
+
+			_, err = c.createFirewall(r)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			cond := r.Target.Status.Conditions.Get(v2.FirewallCreated)
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+		})
+	}
+}
+
+func Test_controller_placementTags(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	newSet := func(name string, replicas int) *v2.FirewallSet {
+		return &v2.FirewallSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test",
+				UID:       types.UID(name + "-uid"),
+			},
+			Spec: v2.FirewallSetSpec{
+				Replicas: replicas,
+			},
+		}
+	}
+
+	var (
+		single = newSet("single", 1)
+		multi  = newSet("multi", 2)
+		deploy = &v2.FirewallDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "fwdeploy",
+				Namespace: "test",
+			},
+			Spec: v2.FirewallDeploymentSpec{
+				Replicas: 2,
+			},
+		}
+	)
+
+	tests := []struct {
+		name string
+		meta metav1.ObjectMeta
+		want []string
+	}{
+		{
+			name: "firewall without owner",
+			meta: metav1.ObjectMeta{},
+			want: nil,
+		},
+		{
+			name: "firewall of a set with a single replica",
+			meta: metav1.ObjectMeta{
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(single, v2.GroupVersion.WithKind("FirewallSet"))},
+			},
+			want: nil,
+		},
+		{
+			name: "firewall of a set with multiple replicas",
+			meta: metav1.ObjectMeta{
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(multi, v2.GroupVersion.WithKind("FirewallSet"))},
+			},
+			want: []string{"cluster-tag"},
+		},
+		{
+			name: "spare of a deployment with multiple replicas",
+			meta: metav1.ObjectMeta{
+				Labels: map[string]string{v2.FirewallSpareLabel: deploy.Name},
+			},
+			want: []string{"cluster-tag"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seed := fake.NewClientBuilder().WithScheme(scheme).WithObjects(single, multi, deploy).Build()
+
+			cc, err := config.New(&config.NewControllerConfig{
+				SeedClient:     seed,
+				ClusterTag:     "cluster-tag",
+				SkipValidation: true,
+			})
+			require.NoError(t, err)
+
+			c := &controller{
+				c: cc,
+			}
+
+			tt.meta.Name = "fw"
+			tt.meta.Namespace = "test"
+
+			got, err := c.placementTags(&controllers.Ctx[*v2.Firewall]{
+				Ctx:    ctx,
+				Log:    testr.New(t),
+				Target: &v2.Firewall{ObjectMeta: tt.meta},
+			})
+			require.NoError(t, err)
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diff (+got -want):\n %s", diff)
+			}
+		})
+	}
+}
+
+func Test_isNoMachineAvailable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *httperrors.HTTPErrorResponse
+		want bool
+	}{
+		{
+			name: "no machine available",
+			err:  httperrors.UnprocessableEntity(errors.New("no machine available")),
+			want: true,
+		},
+		{
+			name: "other allocation error",
+			err:  httperrors.UnprocessableEntity(errors.New("partition:partition-a not found")),
+			want: false,
+		},
+		{
+			name: "other status code",
+			err:  httperrors.InternalServerError(errors.New("no machine available")),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the error is encoded like the metal-api does and decoded by the metal-go client
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := json.Marshal(tt.err)
+				require.NoError(t, err)
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.err.StatusCode)
+				_, _ = w.Write(body)
+			}))
+			defer server.Close()
+
+			m, err := metalgo.NewDriver(server.URL, "", "hmac")
+			require.NoError(t, err)
+
+			_, err = m.Firewall().AllocateFirewall(firewall.NewAllocateFirewallParams().WithBody(&models.V1FirewallCreateRequest{}), nil)
+			require.Error(t, err)
+
+			assert.Equal(t, tt.want, isNoMachineAvailable(err))
+		})
+	}
+
+	assert.False(t, isNoMachineAvailable(errors.New(noMachineAvailableMessage)))
+}
@@ -55,6 +55,14 @@ func getMachineStatus(f *models.V1FirewallResponse) (*v2.MachineStatus, error) {
 		AllocationTimestamp: metav1.NewTime(time.Time(*f.Allocation.Created)),
 		Liveliness:          *f.Liveliness,
 		ImageID:             pointer.SafeDeref(f.Allocation.Image.ID),
+		Rack:                f.Rackid,
+	}
+
+	if f.Size != nil {
+		result.Size = pointer.SafeDeref(f.Size.ID)
+	}
+	if f.Partition != nil {
+		result.Partition = pointer.SafeDeref(f.Partition.ID)
 	}
 
 	if f.Events != nil && f.Events.CrashLoop != nil {
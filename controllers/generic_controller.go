@@ -13,6 +13,7 @@ import (
 
 	"github.com/go-logr/logr"
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/config"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -33,11 +34,10 @@ type (
 	}
 
 	GenericController[O client.Object] struct {
-		l          logr.Logger
-		namespace  string
-		c          client.Client
-		reconciler Reconciler[O]
-		hasStatus  bool
+		l           logr.Logger
+		c           client.Client
+		reconcilers map[string]Reconciler[O]
+		hasStatus   bool
 	}
 )
 
@@ -56,14 +56,32 @@ func RequeueAfter(d time.Duration, reason string) error {
 
 func NewGenericController[O client.Object](l logr.Logger, c client.Client, namespace string, reconciler Reconciler[O]) *GenericController[O] {
 	return &GenericController[O]{
-		l:          l,
-		c:          c,
-		namespace:  namespace,
-		reconciler: reconciler,
-		hasStatus:  true,
+		l: l,
+		c: c,
+		reconcilers: map[string]Reconciler[O]{
+			namespace: reconciler,
+		},
+		hasStatus: true,
 	}
 }
 
+// NewNamespacedGenericController creates a generic controller for all seed namespaces of the given configs.
+// the resources of every namespace are reconciled by a dedicated reconciler created from the config of the namespace.
+func NewNamespacedGenericController[O client.Object](l logr.Logger, configs *config.ControllerConfigs, newReconciler func(c *config.ControllerConfig) Reconciler[O]) *GenericController[O] {
+	g := &GenericController[O]{
+		l:           l,
+		c:           configs.GetSeedClient(),
+		reconcilers: map[string]Reconciler[O]{},
+		hasStatus:   true,
+	}
+
+	for _, c := range configs.GetAll() {
+		g.reconcilers[c.GetSeedNamespace()] = newReconciler(c)
+	}
+
+	return g
+}
+
 func (g *GenericController[O]) WithoutStatus() *GenericController[O] {
 	g.hasStatus = false
 	return g
@@ -74,12 +92,13 @@ func (g *GenericController[O]) logger(req ctrl.Request) logr.Logger {
 }
 
 func (g GenericController[O]) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	if req.Namespace != g.namespace { // should already be filtered out through predicate, but we will check anyway
+	reconciler, ok := g.reconcilers[req.Namespace]
+	if !ok { // should already be filtered out through predicate, but we will check anyway
 		return ctrl.Result{}, nil
 	}
 
 	var (
-		o    = g.reconciler.New()
+		o    = reconciler.New()
 		log  = g.logger(req)
 		rctx = &Ctx[O]{
 			Ctx:               ctx,
@@ -101,7 +120,7 @@ func (g GenericController[O]) Reconcile(ctx context.Context, req ctrl.Request) (
 	if !o.GetDeletionTimestamp().IsZero() {
 		if controllerutil.ContainsFinalizer(o, v2.FinalizerName) {
 			log.Info("reconciling resource deletion flow")
-			err := g.reconciler.Delete(rctx)
+			err := reconciler.Delete(rctx)
 			if err != nil {
 				if requeueErr, ok := errors.AsType[*requeueError](err); ok {
 					log.Info(requeueErr.Error())
@@ -146,7 +165,7 @@ func (g GenericController[O]) Reconcile(ctx context.Context, req ctrl.Request) (
 	if g.hasStatus {
 		defer func() {
 			log.Info("updating status")
-			obj := g.reconciler.New()
+			obj := reconciler.New()
 
 			statusErr = g.c.Get(ctx, req.NamespacedName, obj, &client.GetOptions{})
 			if statusErr != nil {
@@ -154,7 +173,7 @@ func (g GenericController[O]) Reconcile(ctx context.Context, req ctrl.Request) (
 				return
 			}
 
-			g.reconciler.SetStatus(o, obj)
+			reconciler.SetStatus(o, obj)
 
 			statusErr = g.c.Status().Update(ctx, obj)
 			if statusErr != nil {
@@ -169,7 +188,7 @@ func (g GenericController[O]) Reconcile(ctx context.Context, req ctrl.Request) (
 		rctx.WithinMaintenance = true
 
 		defer func() {
-			obj := g.reconciler.New()
+			obj := reconciler.New()
 
 			err := g.c.Get(ctx, req.NamespacedName, obj, &client.GetOptions{})
 			if err != nil {
@@ -189,7 +208,7 @@ func (g GenericController[O]) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	log.Info("reconciling resource")
 
-	err := g.reconciler.Reconcile(rctx)
+	err := reconciler.Reconcile(rctx)
 	if err != nil {
 		if requeueErr, ok := errors.AsType[*requeueError](err); ok {
 			log.Info(requeueErr.Error())
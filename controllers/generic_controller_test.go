@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr/testr"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type namespaceRecorder struct {
+	namespace  string
+	reconciled []string
+}
+
+func (n *namespaceRecorder) New() *v2.FirewallSet {
+	return &v2.FirewallSet{}
+}
+
+func (n *namespaceRecorder) SetStatus(_ *v2.FirewallSet, _ *v2.FirewallSet) {}
+
+func (n *namespaceRecorder) Reconcile(r *Ctx[*v2.FirewallSet]) error {
+	n.reconciled = append(n.reconciled, n.namespace+"/"+r.Target.Name)
+	return nil
+}
+
+func (n *namespaceRecorder) Delete(_ *Ctx[*v2.FirewallSet]) error {
+	return nil
+}
+
+func TestNewNamespacedGenericController(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&v2.FirewallSet{ObjectMeta: metav1.ObjectMeta{Name: "set", Namespace: "a"}},
+		&v2.FirewallSet{ObjectMeta: metav1.ObjectMeta{Name: "set", Namespace: "b"}},
+		&v2.FirewallSet{ObjectMeta: metav1.ObjectMeta{Name: "set", Namespace: "c"}},
+	).Build()
+
+	var ccs []*config.ControllerConfig
+	for _, ns := range []string{"b", "a"} {
+		cc, err := config.New(&config.NewControllerConfig{
+			SeedClient:     c,
+			SeedNamespace:  ns,
+			SkipValidation: true,
+		})
+		require.NoError(t, err)
+
+		ccs = append(ccs, cc)
+	}
+
+	configs, err := config.NewControllerConfigs(ccs...)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, configs.GetSeedNamespaces())
+
+	reconcilers := map[string]*namespaceRecorder{}
+
+	g := NewNamespacedGenericController(testr.New(t), configs, func(c *config.ControllerConfig) Reconciler[*v2.FirewallSet] {
+		r := &namespaceRecorder{namespace: c.GetSeedNamespace()}
+		reconcilers[c.GetSeedNamespace()] = r
+		return r
+	}).WithoutStatus()
+
+	for _, ns := range []string{"a", "b", "c"} {
+		_, err := g.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: ns, Name: "set"}})
+		require.NoError(t, err)
+	}
+
+	require.Len(t, reconcilers, 2)
+	assert.Equal(t, []string{"a/set"}, reconcilers["a"].reconciled)
+	assert.Equal(t, []string{"b/set"}, reconcilers["b"].reconciled)
+
+	_, err = config.NewControllerConfigs(ccs[0], ccs[0])
+	require.EqualError(t, err, `duplicate controller config for seed namespace "b"`)
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	metalgo "github.com/metal-stack/metal-go"
+	"github.com/metal-stack/metal-go/api/client/firewall"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrFirewallNotImportable is returned when a firewall of the metal-api cannot be imported, retrying the import does not help then.
+var ErrFirewallNotImportable = errors.New("firewall cannot be imported")
+
+// ImportFirewall creates a firewall resource for an existing firewall of the metal-api, such that no new firewall gets allocated
+// for it. The given firewall is used as a template for the resource, which is named after the hostname of the machine.
+// Returns nil if the firewall was already imported before.
+func ImportFirewall(ctx context.Context, c client.Client, m metalgo.Client, machineID string, template *v2.Firewall) (*v2.Firewall, error) {
+	resp, err := m.Firewall().FindFirewall(firewall.NewFindFirewallParams().WithID(machineID).WithContext(ctx), nil)
+	if err != nil {
+		var defaultErr *firewall.FindFirewallDefault
+		if errors.As(err, &defaultErr) && defaultErr.Code() == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: firewall %s not found", ErrFirewallNotImportable, machineID)
+		}
+
+		return nil, fmt.Errorf("firewall find error: %w", err)
+	}
+
+	allocation := resp.Payload.Allocation
+	if allocation == nil {
+		return nil, fmt.Errorf("%w: firewall %s is not allocated", ErrFirewallNotImportable, machineID)
+	}
+
+	// the firewall controller only finds firewalls whose project and hostname match the firewall resource
+	if project := pointer.SafeDeref(allocation.Project); project != template.Spec.Project {
+		return nil, fmt.Errorf("%w: firewall %s is allocated in project %q instead of %q", ErrFirewallNotImportable, machineID, project, template.Spec.Project)
+	}
+
+	name := pointer.SafeDeref(allocation.Hostname)
+	if name == "" {
+		return nil, fmt.Errorf("%w: firewall %s has no hostname", ErrFirewallNotImportable, machineID)
+	}
+
+	// an imported firewall that does not match the template would immediately be reported as drifted and replaced
+	if diffs := MachineDrift(&template.Spec, resp.Payload); len(diffs) > 0 {
+		return nil, fmt.Errorf("%w: firewall %s does not match the template: %s", ErrFirewallNotImportable, machineID, strings.Join(diffs, ", "))
+	}
+
+	existing := &v2.Firewall{}
+	err = c.Get(ctx, client.ObjectKey{Namespace: template.Namespace, Name: name}, existing)
+	if err == nil {
+		if existing.Annotations[v2.FirewallImportedMachineIDAnnotation] == machineID ||
+			(existing.Status.MachineStatus != nil && existing.Status.MachineStatus.MachineID == machineID) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("%w: firewall resource %q already exists", ErrFirewallNotImportable, name)
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("unable to get firewall: %w", err)
+	}
+
+	fw := template.DeepCopy()
+	fw.Name = name
+
+	if fw.Annotations == nil {
+		fw.Annotations = map[string]string{}
+	}
+	fw.Annotations[v2.FirewallImportedMachineIDAnnotation] = machineID
+
+	err = c.Create(ctx, fw)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create firewall resource: %w", err)
+	}
+
+	fw.Status.MachineStatus = &v2.MachineStatus{
+		MachineID:           machineID,
+		AllocationTimestamp: metav1.NewTime(time.Time(pointer.SafeDeref(allocation.Created))),
+		Liveliness:          pointer.SafeDeref(resp.Payload.Liveliness),
+	}
+
+	err = c.Status().Update(ctx, fw)
+	if err != nil {
+		return nil, fmt.Errorf("unable to update firewall status: %w", err)
+	}
+
+	return fw, nil
+}
+
+// MachineID returns the machine id of the firewall from its status. Imported firewalls carry the machine id in an annotation
+// until the status is populated.
+func MachineID(fw *v2.Firewall) string {
+	if fw.Status.MachineStatus != nil && fw.Status.MachineStatus.MachineID != "" {
+		return fw.Status.MachineStatus.MachineID
+	}
+
+	return fw.Annotations[v2.FirewallImportedMachineIDAnnotation]
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/metal-go/api/client/firewall"
+	"github.com/metal-stack/metal-go/api/models"
+	metaltestclient "github.com/metal-stack/metal-go/test/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestImportFirewall(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	template := &v2.Firewall{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+			Labels: map[string]string{
+				"purpose": "shoot-firewall",
+			},
+		},
+		Spec: v2.FirewallSpec{
+			Size:      "size-a",
+			Partition: "partition-a",
+			Image:     "firewall-ubuntu-3.0",
+			Project:   "project-a",
+			Networks:  []string{"internet", "private"},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		machineID string
+		existing  []client.Object
+		wantName  string
+		wantErr   bool
+	}{
+		{
+			name:      "imports firewall",
+			machineID: "1",
+			wantName:  "fw-a",
+		},
+		{
+			name:      "already imported",
+			machineID: "1",
+			existing: []client.Object{&v2.Firewall{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "fw-a",
+					Namespace:   "test",
+					Annotations: map[string]string{v2.FirewallImportedMachineIDAnnotation: "1"},
+				},
+			}},
+		},
+		{
+			name:      "firewall resource with the same name exists",
+			machineID: "1",
+			existing: []client.Object{&v2.Firewall{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fw-a",
+					Namespace: "test",
+				},
+			}},
+			wantErr: true,
+		},
+		{
+			name:      "firewall in other project",
+			machineID: "2",
+			wantErr:   true,
+		},
+		{
+			name:      "firewall does not match the template",
+			machineID: "4",
+			wantErr:   true,
+		},
+		{
+			name:      "firewall not found",
+			machineID: "3",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, mc := metaltestclient.NewMetalMockClient(t, &metaltestclient.MetalMockFns{
+				Firewall: func(m *mock.Mock) {
+					m.On("FindFirewall", firewall.NewFindFirewallParams().WithID("1").WithContext(ctx), nil).Return(&firewall.FindFirewallOK{Payload: &models.V1FirewallResponse{
+						ID:         new("1"),
+						Liveliness: new("Alive"),
+						Size:       &models.V1SizeResponse{ID: new("size-a")},
+						Partition:  &models.V1PartitionResponse{ID: new("partition-a")},
+						Allocation: &models.V1MachineAllocation{
+							Hostname: new("fw-a"),
+							Project:  new("project-a"),
+							Created:  new(strfmt.DateTime{}),
+							Image:    &models.V1ImageResponse{ID: new("firewall-ubuntu-3.0.20260101")},
+							Networks: []*models.V1MachineNetwork{
+								{Networkid: new("private")},
+								{Networkid: new("internet")},
+							},
+						},
+					}}, nil).Maybe()
+					m.On("FindFirewall", firewall.NewFindFirewallParams().WithID("2").WithContext(ctx), nil).Return(&firewall.FindFirewallOK{Payload: &models.V1FirewallResponse{
+						ID: new("2"),
+						Allocation: &models.V1MachineAllocation{
+							Hostname: new("fw-b"),
+							Project:  new("project-b"),
+						},
+					}}, nil).Maybe()
+					m.On("FindFirewall", firewall.NewFindFirewallParams().WithID("4").WithContext(ctx), nil).Return(&firewall.FindFirewallOK{Payload: &models.V1FirewallResponse{
+						ID:        new("4"),
+						Size:      &models.V1SizeResponse{ID: new("size-b")},
+						Partition: &models.V1PartitionResponse{ID: new("partition-a")},
+						Allocation: &models.V1MachineAllocation{
+							Hostname: new("fw-d"),
+							Project:  new("project-a"),
+							Image:    &models.V1ImageResponse{ID: new("firewall-ubuntu-2.0.20250101")},
+							Networks: []*models.V1MachineNetwork{
+								{Networkid: new("internet")},
+							},
+						},
+					}}, nil).Maybe()
+					m.On("FindFirewall", firewall.NewFindFirewallParams().WithID("3").WithContext(ctx), nil).Return(nil, firewall.NewFindFirewallDefault(http.StatusNotFound)).Maybe()
+				},
+			})
+
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.existing...).WithStatusSubresource(&v2.Firewall{}).Build()
+
+			fw, err := ImportFirewall(ctx, c, mc, tt.machineID, template)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrFirewallNotImportable)
+				return
+			}
+			require.NoError(t, err)
+
+			if tt.wantName == "" {
+				assert.Nil(t, fw)
+				return
+			}
+
+			refetched := &v2.Firewall{}
+			require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "test", Name: tt.wantName}, refetched))
+
+			assert.Equal(t, tt.machineID, refetched.Annotations[v2.FirewallImportedMachineIDAnnotation])
+			assert.Equal(t, "shoot-firewall", refetched.Labels["purpose"])
+			require.NotNil(t, refetched.Status.MachineStatus)
+			assert.Equal(t, tt.machineID, refetched.Status.MachineStatus.MachineID)
+			assert.Equal(t, "Alive", refetched.Status.MachineStatus.Liveliness)
+			assert.Nil(t, metav1.GetControllerOf(refetched))
+
+			// the template must not be altered
+			assert.Empty(t, template.Name)
+			assert.Empty(t, template.Annotations)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package orphan
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/events"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/metal-stack/firewall-controller-manager/api/v2/config"
+)
+
+var (
+	orphanedFirewalls = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "firewall_orphaned_machines",
+		Help: "provide information on firewalls in the metal-api without a firewall resource",
+	}, []string{"namespace"})
+	freedOrphanedFirewalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "firewall_orphaned_machines_freed_total",
+		Help: "provide information on orphaned firewalls that were freed after the grace period",
+	}, []string{"namespace"})
+
+	registerMetricsOnce sync.Once
+)
+
+// controller periodically looks up firewalls of the cluster in the metal-api for which no firewall resource exists
+// in the seed namespace anymore. this can happen when a finalizer was removed by hand, a namespace was force-deleted
+// or the controller crashed during firewall creation.
+type controller struct {
+	c        *config.ControllerConfig
+	log      logr.Logger
+	recorder events.EventRecorder
+
+	// firstSeen contains the machine ids of orphaned firewalls and the time when they were first found orphaned
+	firstSeen map[string]time.Time
+	now       func() time.Time
+}
+
+func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.Manager, configs *config.ControllerConfigs) error {
+	registerMetricsOnce.Do(func() {
+		metrics.Registry.MustRegister(orphanedFirewalls, freedOrphanedFirewalls)
+	})
+
+	for _, c := range configs.GetAll() {
+		if c.GetOrphanCheckInterval() <= 0 {
+			continue
+		}
+
+		ctrl := &controller{
+			c:         c,
+			log:       log.WithValues("namespace", c.GetSeedNamespace()),
+			recorder:  recorder,
+			firstSeen: map[string]time.Time{},
+			now:       time.Now,
+		}
+
+		// runnables require leader election by default, so only one instance frees orphaned firewalls
+		err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			wait.UntilWithContext(ctx, func(ctx context.Context) {
+				if err := ctrl.collect(ctx); err != nil {
+					ctrl.log.Error(err, "unable to collect orphaned firewalls")
+				}
+			}, c.GetOrphanCheckInterval())
+
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
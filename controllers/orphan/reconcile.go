@@ -0,0 +1,138 @@
+package orphan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/metal-stack/metal-go/api/client/firewall"
+	"github.com/metal-stack/metal-go/api/client/machine"
+	"github.com/metal-stack/metal-go/api/models"
+	"github.com/metal-stack/metal-lib/pkg/pointer"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// collect reports firewalls of the cluster in the metal-api without a firewall resource in the seed namespace and
+// frees them when they stay orphaned for longer than the grace period.
+func (c *controller) collect(ctx context.Context) error {
+	orphans, err := c.findOrphans(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		namespace   = c.c.GetSeedNamespace()
+		gracePeriod = c.c.GetOrphanGracePeriod()
+		now         = c.now()
+		seen        = sets.New[string]()
+		errs        []error
+	)
+
+	orphanedFirewalls.WithLabelValues(namespace).Set(float64(len(orphans)))
+
+	for _, f := range orphans {
+		var (
+			id   = pointer.SafeDeref(f.ID)
+			name = pointer.SafeDeref(pointer.SafeDeref(f.Allocation).Name)
+		)
+
+		seen.Insert(id)
+
+		firstSeen, ok := c.firstSeen[id]
+		if !ok {
+			c.firstSeen[id] = now
+			firstSeen = now
+
+			c.log.Info("found orphaned firewall", "firewall-name", name, "id", id)
+			c.recorder.Eventf(c.namespaceRef(), nil, corev1.EventTypeWarning, "Orphaned", "detecting orphan", "firewall %s id %s has no firewall resource", name, id)
+		}
+
+		if gracePeriod <= 0 || now.Sub(firstSeen) < gracePeriod {
+			continue
+		}
+
+		_, err := c.c.GetMetal().Machine().FreeMachine(machine.NewFreeMachineParams().WithID(id).WithContext(ctx), nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to free orphaned firewall %s: %w", id, err))
+			continue
+		}
+
+		delete(c.firstSeen, id)
+		freedOrphanedFirewalls.WithLabelValues(namespace).Inc()
+
+		c.log.Info("freed orphaned firewall", "firewall-name", name, "id", id, "orphaned-since", firstSeen)
+		c.recorder.Eventf(c.namespaceRef(), nil, corev1.EventTypeNormal, "Delete", "freeing orphan", "freed firewall %s id %s after being orphaned for %s", name, id, gracePeriod)
+	}
+
+	// firewalls that are not orphaned anymore, e.g. because a firewall resource was restored, need to be
+	// observed for the whole grace period again
+	for id := range c.firstSeen {
+		if !seen.Has(id) {
+			delete(c.firstSeen, id)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// findOrphans returns the firewalls of the cluster in the metal-api for which no firewall resource exists in the seed namespace.
+func (c *controller) findOrphans(ctx context.Context) ([]*models.V1FirewallResponse, error) {
+	resp, err := c.c.GetMetal().Firewall().FindFirewalls(firewall.NewFindFirewallsParams().WithBody(&models.V1FirewallFindRequest{
+		Tags: []string{c.c.GetClusterTag(), v2.FirewallManagedByTag()},
+	}).WithContext(ctx), nil)
+	if err != nil {
+		return nil, fmt.Errorf("firewall search error: %w", err)
+	}
+
+	// the firewall list must be retrieved after the metal-api lookup, otherwise a firewall that was just created
+	// could be considered orphaned
+	fwList := &v2.FirewallList{}
+	err = c.c.GetSeedClient().List(ctx, fwList, client.InNamespace(c.c.GetSeedNamespace()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list firewalls: %w", err)
+	}
+
+	// firewalls are matched by machine id because the names of imported firewalls do not necessarily match the
+	// allocation names. the name is only considered for firewalls whose machine status was not yet populated.
+	var (
+		ids   = sets.New[string]()
+		names = sets.New[string]()
+	)
+	for _, fw := range fwList.Items {
+		if id := controllers.MachineID(&fw); id != "" {
+			ids.Insert(id)
+			continue
+		}
+
+		names.Insert(fw.Name)
+	}
+
+	var orphans []*models.V1FirewallResponse
+	for _, f := range resp.Payload {
+		if f.ID == nil || f.Allocation == nil {
+			continue
+		}
+
+		if ids.Has(*f.ID) || names.Has(pointer.SafeDeref(f.Allocation.Name)) {
+			continue
+		}
+
+		orphans = append(orphans, f)
+	}
+
+	return orphans, nil
+}
+
+// namespaceRef returns the seed namespace for attaching events because orphaned firewalls have no resource.
+func (c *controller) namespaceRef() *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: c.c.GetSeedNamespace(),
+		},
+	}
+}
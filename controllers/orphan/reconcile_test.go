@@ -0,0 +1,180 @@
+package orphan
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/testr"
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/google/go-cmp/cmp"
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/config"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/metal-stack/metal-go/api/client/firewall"
+	"github.com/metal-stack/metal-go/api/client/machine"
+	"github.com/metal-stack/metal-go/api/models"
+	metaltestclient "github.com/metal-stack/metal-go/test/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/events"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_controller_collect(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := k8sruntime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	metalFirewall := func(id, name string) *models.V1FirewallResponse {
+		return &models.V1FirewallResponse{
+			ID: new(id),
+			Allocation: &models.V1MachineAllocation{
+				Name: new(name),
+			},
+		}
+	}
+
+	var (
+		start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		now   = start
+		freed []string
+	)
+
+	fw := &v2.Firewall{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fw-a",
+			Namespace: "test",
+		},
+	}
+
+	mc, _ := metaltestclient.NewMetalMockClient(t, &metaltestclient.MetalMockFns{
+		Firewall: func(m *mock.Mock) {
+			m.On("FindFirewalls", mock.Anything, nil).Return(&firewall.FindFirewallsOK{Payload: []*models.V1FirewallResponse{
+				metalFirewall("1", "fw-a"),
+				metalFirewall("2", "fw-b"),
+			}}, nil)
+		},
+		Machine: func(m *mock.Mock) {
+			m.On("FreeMachine", mock.Anything, nil).Return(func(params *machine.FreeMachineParams, _ runtime.ClientAuthInfoWriter, _ ...machine.ClientOption) (*machine.FreeMachineOK, error) {
+				freed = append(freed, params.ID)
+				return &machine.FreeMachineOK{Payload: &models.V1MachineResponse{ID: new(params.ID)}}, nil
+			}).Maybe()
+		},
+	})
+
+	cc, err := config.New(&config.NewControllerConfig{
+		SeedClient:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(fw).Build(),
+		SeedNamespace:     "test",
+		Metal:             mc,
+		ClusterTag:        "cluster-tag",
+		OrphanGracePeriod: time.Hour,
+		SkipValidation:    true,
+	})
+	require.NoError(t, err)
+
+	c := &controller{
+		c:         cc,
+		log:       testr.New(t),
+		recorder:  events.NewFakeRecorder(10),
+		firstSeen: map[string]time.Time{},
+		now:       func() time.Time { return now },
+	}
+
+	// orphans are only reported within the grace period
+	require.NoError(t, c.collect(ctx))
+	require.Empty(t, freed)
+	require.Equal(t, float64(1), testutil.ToFloat64(orphanedFirewalls.WithLabelValues("test")))
+
+	if diff := cmp.Diff(map[string]time.Time{"2": start}, c.firstSeen); diff != "" {
+		t.Errorf("first seen diff = %s", diff)
+	}
+
+	now = start.Add(30 * time.Minute)
+	require.NoError(t, c.collect(ctx))
+	require.Empty(t, freed)
+
+	// orphans are freed after the grace period
+	now = start.Add(time.Hour)
+	require.NoError(t, c.collect(ctx))
+
+	if diff := cmp.Diff([]string{"2"}, freed); diff != "" {
+		t.Errorf("freed firewalls diff = %s", diff)
+	}
+	require.Empty(t, c.firstSeen)
+	require.Equal(t, float64(1), testutil.ToFloat64(freedOrphanedFirewalls.WithLabelValues("test")))
+}
+
+func Test_controller_findOrphans_imported(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := k8sruntime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	// the hostname of an imported firewall, which is used as the resource name, differs from the allocation name
+	imported := &models.V1FirewallResponse{
+		ID:         new("1"),
+		Liveliness: new("Alive"),
+		Allocation: &models.V1MachineAllocation{
+			Name:     new("legacy-firewall"),
+			Hostname: new("shoot-firewall"),
+			Project:  new("project-a"),
+			Created:  new(strfmt.DateTime{}),
+		},
+	}
+	orphaned := &models.V1FirewallResponse{
+		ID: new("2"),
+		Allocation: &models.V1MachineAllocation{
+			Name: new("fw-b"),
+		},
+	}
+
+	mc, _ := metaltestclient.NewMetalMockClient(t, &metaltestclient.MetalMockFns{
+		Firewall: func(m *mock.Mock) {
+			m.On("FindFirewall", firewall.NewFindFirewallParams().WithID("1").WithContext(ctx), nil).Return(&firewall.FindFirewallOK{Payload: imported}, nil)
+			m.On("FindFirewalls", mock.Anything, nil).Return(&firewall.FindFirewallsOK{Payload: []*models.V1FirewallResponse{imported, orphaned}}, nil)
+		},
+	})
+
+	seed := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&v2.Firewall{}).Build()
+
+	fw, err := controllers.ImportFirewall(ctx, seed, mc, "1", &v2.Firewall{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test",
+		},
+		Spec: v2.FirewallSpec{
+			Project: "project-a",
+		},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, fw)
+	assert.Equal(t, "shoot-firewall", fw.Name)
+
+	cc, err := config.New(&config.NewControllerConfig{
+		SeedClient:     seed,
+		SeedNamespace:  "test",
+		Metal:          mc,
+		ClusterTag:     "cluster-tag",
+		SkipValidation: true,
+	})
+	require.NoError(t, err)
+
+	c := &controller{
+		c:         cc,
+		log:       testr.New(t),
+		recorder:  events.NewFakeRecorder(10),
+		firstSeen: map[string]time.Time{},
+		now:       time.Now,
+	}
+
+	orphans, err := c.findOrphans(ctx)
+	require.NoError(t, err)
+	require.Len(t, orphans, 1)
+	assert.Equal(t, "2", *orphans[0].ID)
+}
@@ -1,9 +1,13 @@
 package controllers
 
-import "sigs.k8s.io/controller-runtime/pkg/client"
+import (
+	"slices"
 
-func SkipOtherNamespace(namespace string) func(object client.Object) bool {
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func SkipOtherNamespace(namespaces ...string) func(object client.Object) bool {
 	return func(object client.Object) bool {
-		return object.GetNamespace() == namespace
+		return slices.Contains(namespaces, object.GetNamespace())
 	}
 }
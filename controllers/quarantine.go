@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// QuarantineFirewall releases the given firewall from its firewall set such that the set creates a replacement.
+// The firewall gets the longest distance in order not to attract any traffic and is kept until the given ttl has expired,
+// which allows analyzing the root cause of a problem on the machine. The quarantine label contains the name of the firewall
+// deployment of the given set, such that the firewall can be cleaned up when the deployment is deleted.
+func QuarantineFirewall(ctx context.Context, c client.Client, fw *v2.Firewall, set *v2.FirewallSet, ttl time.Duration) error {
+	until := time.Now().Add(ttl)
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		refetched := &v2.Firewall{}
+		err := c.Get(ctx, client.ObjectKeyFromObject(fw), refetched)
+		if err != nil {
+			return fmt.Errorf("unable to re-fetch firewall: %w", err)
+		}
+
+		refetched.OwnerReferences = slices.DeleteFunc(refetched.OwnerReferences, func(ref metav1.OwnerReference) bool {
+			return ref.Controller != nil && *ref.Controller
+		})
+		refetched.Distance = v2.FirewallLongestDistance
+
+		if refetched.Labels == nil {
+			refetched.Labels = map[string]string{}
+		}
+		refetched.Labels[v2.FirewallQuarantinedLabel] = deploymentName(set)
+
+		if refetched.Annotations == nil {
+			refetched.Annotations = map[string]string{}
+		}
+		refetched.Annotations[v2.FirewallQuarantinedUntilAnnotation] = until.UTC().Format(time.RFC3339)
+
+		err = c.Update(ctx, refetched)
+		if err != nil {
+			return fmt.Errorf("unable to quarantine firewall: %w", err)
+		}
+
+		refetched.DeepCopyInto(fw)
+
+		return nil
+	})
+}
+
+// IsQuarantined returns true if the given firewall was quarantined.
+func IsQuarantined(fw *v2.Firewall) bool {
+	_, ok := fw.GetLabels()[v2.FirewallQuarantinedLabel]
+	return ok
+}
+
+// QuarantinedUntil returns the point in time until a quarantined firewall is kept.
+func QuarantinedUntil(fw *v2.Firewall) (time.Time, error) {
+	until, err := time.Parse(time.RFC3339, fw.GetAnnotations()[v2.FirewallQuarantinedUntilAnnotation])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse %s annotation: %w", v2.FirewallQuarantinedUntilAnnotation, err)
+	}
+
+	return until, nil
+}
+
+// deploymentName returns the name of the firewall deployment that controls the given set.
+func deploymentName(set *v2.FirewallSet) string {
+	ref := metav1.GetControllerOf(set)
+	if ref == nil || ref.Kind != "FirewallDeployment" {
+		return ""
+	}
+
+	return ref.Name
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestQuarantineFirewall(t *testing.T) {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+
+	set := &v2.FirewallSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "set",
+			Namespace: "test",
+			UID:       "set-uid",
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(&v2.FirewallDeployment{ObjectMeta: metav1.ObjectMeta{Name: "fwdeploy", UID: "fwdeploy-uid"}}, v2.GroupVersion.WithKind("FirewallDeployment")),
+			},
+		},
+	}
+
+	fw := &v2.Firewall{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fw",
+			Namespace: "test",
+			Labels: map[string]string{
+				"purpose": "shoot-firewall",
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(set, v2.GroupVersion.WithKind("FirewallSet")),
+			},
+		},
+		Distance: 3,
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(fw).Build()
+
+	require.NoError(t, QuarantineFirewall(ctx, c, fw, set, time.Hour))
+
+	refetched := &v2.Firewall{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(fw), refetched))
+
+	assert.True(t, IsQuarantined(refetched))
+	assert.Equal(t, "fwdeploy", refetched.Labels[v2.FirewallQuarantinedLabel])
+	assert.Nil(t, metav1.GetControllerOf(refetched))
+	assert.Equal(t, v2.FirewallLongestDistance, refetched.Distance)
+	assert.Equal(t, "shoot-firewall", refetched.Labels["purpose"])
+
+	until, err := QuarantinedUntil(refetched)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), until, time.Minute)
+
+	assert.Equal(t, refetched.ResourceVersion, fw.ResourceVersion)
+}
@@ -19,11 +19,13 @@ type controller struct {
 	c        *config.ControllerConfig
 }
 
-func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.Manager, c *config.ControllerConfig) error {
-	g := controllers.NewGenericController(log, c.GetSeedClient(), c.GetSeedNamespace(), &controller{
-		log:      log,
-		recorder: recorder,
-		c:        c,
+func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.Manager, configs *config.ControllerConfigs) error {
+	g := controllers.NewNamespacedGenericController(log, configs, func(c *config.ControllerConfig) controllers.Reconciler[*v2.FirewallSet] {
+		return &controller{
+			log:      log,
+			recorder: recorder,
+			c:        c,
+		}
 	})
 
 	return ctrl.NewControllerManagedBy(mgr).
@@ -48,12 +50,12 @@ func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.M
 				),
 			),
 		).
-		WithEventFilter(predicate.NewPredicateFuncs(controllers.SkipOtherNamespace(c.GetSeedNamespace()))).
+		WithEventFilter(predicate.NewPredicateFuncs(controllers.SkipOtherNamespace(configs.GetSeedNamespaces()...))).
 		Complete(g)
 }
 
-func SetupWebhookWithManager(log logr.Logger, mgr ctrl.Manager, c *config.ControllerConfig) error {
-	defaulter, err := defaults.NewFirewallSetDefaulter(log, c)
+func SetupWebhookWithManager(log logr.Logger, mgr ctrl.Manager, configs *config.ControllerConfigs) error {
+	defaulter, err := defaults.NewFirewallSetDefaulter(log, configs)
 	if err != nil {
 		return err
 	}
@@ -69,5 +71,13 @@ func (c *controller) New() *v2.FirewallSet {
 }
 
 func (c *controller) SetStatus(reconciled *v2.FirewallSet, refetched *v2.FirewallSet) {
+	// the recreations and conditions are maintained by the timeout controller
+	var (
+		recreations = refetched.Status.Recreations
+		conditions  = refetched.Status.Conditions
+	)
+
 	refetched.Status = reconciled.Status
+	refetched.Status.Recreations = recreations
+	refetched.Status.Conditions = conditions
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/defaults"
 	"github.com/metal-stack/firewall-controller-manager/controllers"
 
 	corev1 "k8s.io/api/core/v1"
@@ -44,3 +45,37 @@ func (c *controller) deleteFirewalls(r *controllers.Ctx[*v2.FirewallSet], fws ..
 
 	return nil
 }
+
+// isQuarantineRequired returns true if the given firewall is unhealthy and the set is configured to quarantine unhealthy firewalls.
+func (c *controller) isQuarantineRequired(r *controllers.Ctx[*v2.FirewallSet], fw *v2.Firewall) bool {
+	if r.Target.Spec.Quarantine == nil || fw.DeletionTimestamp != nil {
+		return false
+	}
+
+	status := v2.EvaluateFirewallStatus(fw, c.c.GetCreateTimeoutFor(r.Target), c.c.GetFirewallHealthTimeoutFor(r.Target))
+
+	switch status.Result {
+	case v2.FirewallStatusUnhealthy, v2.FirewallStatusCreateTimeout, v2.FirewallStatusHealthTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *controller) quarantineFirewall(r *controllers.Ctx[*v2.FirewallSet], fw *v2.Firewall) error {
+	ttl := defaults.DefaultQuarantineTTL
+	if r.Target.Spec.Quarantine.TTL != nil {
+		ttl = r.Target.Spec.Quarantine.TTL.Duration
+	}
+
+	err := controllers.QuarantineFirewall(r.Ctx, c.c.GetSeedClient(), fw, r.Target, ttl)
+	if err != nil {
+		return err
+	}
+
+	r.Log.Info("quarantined firewall", "firewall-name", fw.Name, "ttl", ttl.String())
+
+	c.recorder.Eventf(fw, nil, corev1.EventTypeWarning, "Quarantine", "quarantining firewall", "quarantined firewall %s for %s", fw.Name, ttl.String())
+
+	return nil
+}
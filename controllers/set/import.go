@@ -0,0 +1,65 @@
+package set
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// importFirewalls creates firewall resources for the firewalls of the metal-api given in the import annotation of the set.
+// the imported firewalls are not owned by the set yet, they are adopted afterwards instead of allocating new firewalls.
+func (c *controller) importFirewalls(r *controllers.Ctx[*v2.FirewallSet]) ([]*v2.Firewall, error) {
+	value, ok := r.Target.Annotations[v2.FirewallImportAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	template := &v2.Firewall{
+		ObjectMeta: *firewallMeta(r),
+		Spec:       r.Target.Spec.Template.Spec,
+		Distance:   r.Target.Spec.Distance,
+	}
+	template.Namespace = r.Target.Namespace
+	template.OwnerReferences = nil
+
+	var imported []*v2.Firewall
+
+	for machineID := range strings.SplitSeq(value, ",") {
+		machineID = strings.TrimSpace(machineID)
+		if machineID == "" {
+			continue
+		}
+
+		fw, err := controllers.ImportFirewall(r.Ctx, c.c.GetSeedClient(), c.c.GetMetal(), machineID, template)
+		if err != nil {
+			if errors.Is(err, controllers.ErrFirewallNotImportable) {
+				r.Log.Error(err, "skipping firewall import", "id", machineID)
+				c.recorder.Eventf(r.Target, nil, corev1.EventTypeWarning, "Import", "importing firewall", "unable to import firewall %s: %s", machineID, err)
+				continue
+			}
+
+			return nil, fmt.Errorf("unable to import firewall %s: %w", machineID, err)
+		}
+
+		if fw == nil {
+			continue
+		}
+
+		r.Log.Info("firewall imported", "firewall-name", fw.Name, "id", machineID)
+		c.recorder.Eventf(r.Target, nil, corev1.EventTypeNormal, "Import", "importing firewall", "imported firewall %s id %s", fw.Name, machineID)
+
+		imported = append(imported, fw)
+	}
+
+	err := v2.RemoveAnnotation(r.Ctx, c.c.GetSeedClient(), r.Target, v2.FirewallImportAnnotation)
+	if err != nil {
+		return nil, fmt.Errorf("unable to remove import annotation: %w", err)
+	}
+
+	return imported, nil
+}
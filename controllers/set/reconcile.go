@@ -3,6 +3,7 @@ package set
 import (
 	"fmt"
 	"maps"
+	"slices"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/google/uuid"
@@ -22,6 +23,18 @@ func (c *controller) Reconcile(r *controllers.Ctx[*v2.FirewallSet]) error {
 		return fmt.Errorf("unable to get owned firewalls: %w", err)
 	}
 
+	imported, err := c.importFirewalls(r)
+	if err != nil {
+		return err
+	}
+
+	// imported firewalls might not be visible in the cache yet
+	for _, fw := range imported {
+		if !slices.ContainsFunc(orphaned, func(o *v2.Firewall) bool { return o.Name == fw.Name }) {
+			orphaned = append(orphaned, fw)
+		}
+	}
+
 	adoptions, err := c.adoptFirewalls(r, orphaned)
 	if err != nil {
 		return fmt.Errorf("error when trying to adopt firewalls: %w", err)
@@ -85,7 +98,21 @@ func (c *controller) Reconcile(r *controllers.Ctx[*v2.FirewallSet]) error {
 		r.Log.Info("scale up", "current", currentAmount, "want", r.Target.Spec.Replicas)
 
 		for i := currentAmount; i < r.Target.Spec.Replicas; i++ {
-			fw, err := c.createFirewall(r)
+			fw, err := c.adoptSpare(r)
+			if err != nil {
+				return err
+			}
+
+			if fw != nil {
+				r.Log.Info("spare firewall adopted", "firewall-name", fw.Name)
+
+				c.recorder.Eventf(r.Target, nil, corev1.EventTypeNormal, "Adopt", "adopting spare", "adopted spare firewall %s", fw.Name)
+
+				ownedFirewalls = append(ownedFirewalls, fw)
+				continue
+			}
+
+			fw, err = c.createFirewall(r)
 			if err != nil {
 				return err
 			}
@@ -105,6 +132,15 @@ func (c *controller) Reconcile(r *controllers.Ctx[*v2.FirewallSet]) error {
 			var fw *v2.Firewall
 			fw, ownedFirewalls = pop(ownedFirewalls)
 
+			if c.isQuarantineRequired(r, fw) {
+				err := c.quarantineFirewall(r, fw)
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
 			err := c.deleteFirewalls(r, fw)
 			if err != nil {
 				return err
@@ -134,14 +170,35 @@ func (c *controller) createFirewall(r *controllers.Ctx[*v2.FirewallSet]) (*v2.Fi
 	clusterName := r.Target.Namespace
 	name := fmt.Sprintf("%s-firewall-%s", clusterName, uuid.String()[:5])
 
-	meta := r.Target.Spec.Template.ObjectMeta.DeepCopy()
+	meta := firewallMeta(r)
 	meta.Name = name
 	meta.Namespace = r.Target.Namespace
+
+	fw := &v2.Firewall{
+		ObjectMeta: *meta,
+		Spec:       r.Target.Spec.Template.Spec,
+		Distance:   r.Target.Spec.Distance,
+	}
+
+	err = c.c.GetSeedClient().Create(r.Ctx, fw, &client.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create firewall resource: %w", err)
+	}
+
+	return fw, nil
+}
+
+// firewallMeta returns the object meta for a firewall of the given set.
+func firewallMeta(r *controllers.Ctx[*v2.FirewallSet]) *metav1.ObjectMeta {
+	meta := r.Target.Spec.Template.ObjectMeta.DeepCopy()
 	meta.OwnerReferences = []metav1.OwnerReference{
 		*metav1.NewControllerRef(r.Target, v2.GroupVersion.WithKind("FirewallSet")),
 	}
 
 	// inheriting labels from the firewall set to the firewall
+	if meta.Labels == nil {
+		meta.Labels = map[string]string{}
+	}
 	maps.Copy(meta.Labels, r.Target.Labels)
 
 	if v, err := semver.NewVersion(r.Target.Spec.Template.Spec.ControllerVersion); err == nil && v.LessThan(semver.MustParse("v2.0.0")) {
@@ -160,15 +217,67 @@ func (c *controller) createFirewall(r *controllers.Ctx[*v2.FirewallSet]) (*v2.Fi
 		}
 	}
 
-	fw := &v2.Firewall{
-		ObjectMeta: *meta,
-		Spec:       r.Target.Spec.Template.Spec,
-		Distance:   r.Target.Spec.Distance,
+	return meta
+}
+
+// adoptSpare adopts a spare firewall of the deployment the set belongs to. returns nil if no suitable spare is available.
+func (c *controller) adoptSpare(r *controllers.Ctx[*v2.FirewallSet]) (*v2.Firewall, error) {
+	ref := metav1.GetControllerOf(r.Target)
+	if ref == nil || ref.Kind != "FirewallDeployment" {
+		return nil, nil
 	}
 
-	err = c.c.GetSeedClient().Create(r.Ctx, fw, &client.CreateOptions{})
+	fwList := &v2.FirewallList{}
+	err := c.c.GetSeedClient().List(r.Ctx, fwList, client.InNamespace(r.Target.Namespace), client.MatchingLabels{
+		v2.FirewallSpareLabel: ref.Name,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("unable to create firewall resource: %w", err)
+		return nil, fmt.Errorf("unable to list spare firewalls: %w", err)
+	}
+
+	var spares []*v2.Firewall
+	for _, spare := range fwList.GetItems() {
+		if spare.DeletionTimestamp != nil || metav1.GetControllerOf(spare) != nil {
+			continue
+		}
+
+		if !controllers.IsSpareCompatible(spare, &r.Target.Spec.Template.Spec) {
+			continue
+		}
+
+		spares = append(spares, spare)
+	}
+
+	if len(spares) == 0 {
+		return nil, nil
+	}
+
+	// ready spares are preferred, but even an unready spare is provisioned earlier than a new firewall
+	controllers.SortSparesByReadiness(spares)
+
+	var (
+		fw   = spares[0]
+		meta = firewallMeta(r)
+	)
+
+	delete(fw.Labels, v2.FirewallSpareLabel)
+	maps.Copy(fw.Labels, meta.Labels)
+
+	if len(meta.Annotations) > 0 {
+		if fw.Annotations == nil {
+			fw.Annotations = map[string]string{}
+		}
+		maps.Copy(fw.Annotations, meta.Annotations)
+	}
+
+	fw.OwnerReferences = append(fw.OwnerReferences, meta.OwnerReferences...)
+	fw.Spec = r.Target.Spec.Template.Spec
+	fw.Distance = r.Target.Spec.Distance
+
+	// an update conflict means that the spare was adopted concurrently, the reconciliation is retried then
+	err = c.c.GetSeedClient().Update(r.Ctx, fw)
+	if err != nil {
+		return nil, fmt.Errorf("unable to adopt spare firewall: %w", err)
 	}
 
 	return fw, nil
@@ -198,6 +307,16 @@ func (c *controller) adoptFirewall(r *controllers.Ctx[*v2.FirewallSet], fw *v2.F
 		return false, nil
 	}
 
+	if controllers.IsQuarantined(fw) {
+		// quarantined firewalls were released on purpose
+		return false, nil
+	}
+
+	if controllers.IsSpare(fw) {
+		// spares are only adopted on scale up
+		return false, nil
+	}
+
 	ref := metav1.GetControllerOf(fw)
 	if ref != nil && ref.UID != r.Target.UID {
 		// the firewall belongs to some other controller
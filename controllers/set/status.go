@@ -12,7 +12,7 @@ func (c *controller) setStatus(r *controllers.Ctx[*v2.FirewallSet], ownedFirewal
 	r.Target.Status.UnhealthyReplicas = 0
 
 	for _, fw := range ownedFirewalls {
-		status := v2.EvaluateFirewallStatus(fw, c.c.GetCreateTimeout(), c.c.GetFirewallHealthTimeout())
+		status := v2.EvaluateFirewallStatus(fw, c.c.GetCreateTimeoutFor(r.Target), c.c.GetFirewallHealthTimeoutFor(r.Target))
 
 		switch status.Result {
 		case v2.FirewallStatusReady:
@@ -121,17 +121,20 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).ToNot(HaveOccurred())
 
+	configs, err := controllerconfig.NewControllerConfigs(cc)
+	Expect(err).ToNot(HaveOccurred())
+
 	err = set.SetupWithManager(
 		ctrl.Log.WithName("controllers").WithName("set"),
 		mgr.GetEventRecorder("firewall-set-controller"),
 		mgr,
-		cc,
+		configs,
 	)
 	Expect(err).ToNot(HaveOccurred())
-	err = set.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), mgr, cc)
+	err = set.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), mgr, configs)
 	Expect(err).ToNot(HaveOccurred())
 
-	err = firewall.SetupWebhookWithManager(ctrl.Log.WithName("controllers").WithName("firewall"), mgr, cc)
+	err = firewall.SetupWebhookWithManager(ctrl.Log.WithName("controllers").WithName("firewall"), mgr, configs)
 	Expect(err).ToNot(HaveOccurred())
 
 	//+kubebuilder:scaffold:scheme
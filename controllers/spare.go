@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"slices"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// IsSpare returns true if the given firewall is a spare firewall of a firewall deployment.
+func IsSpare(fw *v2.Firewall) bool {
+	_, ok := fw.GetLabels()[v2.FirewallSpareLabel]
+	return ok
+}
+
+// IsSpareCompatible returns true if the given spare firewall can be used for the given firewall spec, which is the case
+// if the spare runs on a machine that would also be allocated for the spec.
+func IsSpareCompatible(fw *v2.Firewall, spec *v2.FirewallSpec) bool {
+	fwS := &fw.Spec
+
+	if fwS.Size != spec.Size || fwS.Image != spec.Image || fwS.Partition != spec.Partition || fwS.Project != spec.Project {
+		return false
+	}
+
+	return sets.New(fwS.Networks...).Equal(sets.New(spec.Networks...))
+}
+
+// IsSpareReady returns true if the given spare firewall is ready to attract traffic.
+func IsSpareReady(fw *v2.Firewall) bool {
+	// timeouts are irrelevant for determining readiness
+	return v2.EvaluateFirewallStatus(fw, 0, 0).Result == v2.FirewallStatusReady
+}
+
+// SortSparesByReadiness sorts the given spare firewalls such that ready spares come first.
+func SortSparesByReadiness(fws []*v2.Firewall) {
+	slices.SortStableFunc(fws, func(a, b *v2.Firewall) int {
+		switch aReady, bReady := IsSpareReady(a), IsSpareReady(b); {
+		case aReady == bReady:
+			return 0
+		case aReady:
+			return -1
+		default:
+			return 1
+		}
+	})
+}
@@ -1,6 +1,8 @@
 package timeout
 
 import (
+	"context"
+
 	"github.com/go-logr/logr"
 	"k8s.io/client-go/tools/events"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -20,18 +22,26 @@ type controller struct {
 	recorder  events.EventRecorder
 }
 
-func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.Manager, c *config.ControllerConfig) error {
-	if c.GetFirewallHealthTimeout() <= 0 && c.GetCreateTimeout() <= 0 {
-		log.Info("not registering timeout controller because neither create nor health timeout configured")
-		return nil
-	}
+// namespacedController dispatches the reconciliation of a firewall set to the controller of the set's namespace.
+type namespacedController map[string]*controller
 
-	g := &controller{
-		c:         c,
-		log:       log,
-		client:    c.GetSeedClient(),
-		namespace: c.GetSeedNamespace(),
-		recorder:  recorder,
+func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.Manager, configs *config.ControllerConfigs) error {
+	var (
+		g          = namespacedController{}
+		namespaces []string
+	)
+
+	// the controller is registered for all namespaces even if no global timeouts are configured
+	// because timeouts can also be specified in the firewall sets
+	for _, c := range configs.GetAll() {
+		g[c.GetSeedNamespace()] = &controller{
+			c:         c,
+			log:       log,
+			client:    c.GetSeedClient(),
+			namespace: c.GetSeedNamespace(),
+			recorder:  recorder,
+		}
+		namespaces = append(namespaces, c.GetSeedNamespace())
 	}
 
 	return ctrl.NewControllerManagedBy(mgr).
@@ -39,6 +49,15 @@ func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.M
 			&v2.FirewallSet{},
 		).
 		Named("FirewallHealthTimeout").
-		WithEventFilter(predicate.NewPredicateFuncs(controllers.SkipOtherNamespace(c.GetSeedNamespace()))).
+		WithEventFilter(predicate.NewPredicateFuncs(controllers.SkipOtherNamespace(namespaces...))).
 		Complete(g)
 }
+
+func (n namespacedController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	c, ok := n[req.Namespace]
+	if !ok {
+		return ctrl.Result{}, nil
+	}
+
+	return c.Reconcile(ctx, req)
+}
@@ -7,8 +7,10 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/google/go-cmp/cmp"
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
 	controllerconfig "github.com/metal-stack/firewall-controller-manager/api/v2/config"
+	"github.com/metal-stack/firewall-controller-manager/controllers"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -25,7 +27,9 @@ func TestTimeoutController_deleteIfUnhealthyOrTimeout(t *testing.T) {
 		name          string
 		createTimeout time.Duration
 		healthTimeout time.Duration
+		set           *v2.FirewallSet
 		firewall      func(now time.Time, name string) *v2.Firewall
+		others        func(now time.Time) []*v2.Firewall
 		wantDeleted   bool
 		wantRequeue   bool
 	}{
@@ -36,8 +40,44 @@ func TestTimeoutController_deleteIfUnhealthyOrTimeout(t *testing.T) {
 				// Seed connection has been false for 10 minutes => timeout exceeded.
 				return newFirewall(name, now.Add(-10*time.Minute), v2.ConditionFalse)
 			},
+			others: func(now time.Time) []*v2.Firewall {
+				return []*v2.Firewall{newFirewall("healthy", now.Add(-10*time.Minute), v2.ConditionTrue)}
+			},
 			wantDeleted: true,
 		},
+		{
+			name:          "does not delete last ready firewall after health timeout",
+			healthTimeout: 5 * time.Minute,
+			firewall: func(now time.Time, name string) *v2.Firewall {
+				return newFirewall(name, now.Add(-10*time.Minute), v2.ConditionFalse)
+			},
+		},
+		{
+			name:          "does not delete firewall after health timeout when all firewalls timed out",
+			healthTimeout: 5 * time.Minute,
+			firewall: func(now time.Time, name string) *v2.Firewall {
+				return newFirewall(name, now.Add(-10*time.Minute), v2.ConditionFalse)
+			},
+			others: func(now time.Time) []*v2.Firewall {
+				// the other firewall comes first and is deleted, so the firewall under test is the last ready one
+				return []*v2.Firewall{newFirewall("a-timed-out", now.Add(-10*time.Minute), v2.ConditionFalse)}
+			},
+		},
+		{
+			name:          "respects min available of the set",
+			healthTimeout: 5 * time.Minute,
+			set: &v2.FirewallSet{
+				Spec: v2.FirewallSetSpec{
+					MinAvailable: new(2),
+				},
+			},
+			firewall: func(now time.Time, name string) *v2.Firewall {
+				return newFirewall(name, now.Add(-10*time.Minute), v2.ConditionFalse)
+			},
+			others: func(now time.Time) []*v2.Firewall {
+				return []*v2.Firewall{newFirewall("healthy", now.Add(-10*time.Minute), v2.ConditionTrue)}
+			},
+		},
 		{
 			name:          "requeues before health timeout",
 			healthTimeout: 5 * time.Minute,
@@ -55,6 +95,39 @@ func TestTimeoutController_deleteIfUnhealthyOrTimeout(t *testing.T) {
 			},
 			wantDeleted: true,
 		},
+		{
+			name: "deletes firewall after health timeout of the set",
+			set: &v2.FirewallSet{
+				Spec: v2.FirewallSetSpec{
+					HealthTimeout: &metav1.Duration{Duration: 5 * time.Minute},
+				},
+			},
+			firewall: func(now time.Time, name string) *v2.Firewall {
+				return newFirewall(name, now.Add(-10*time.Minute), v2.ConditionFalse)
+			},
+			others: func(now time.Time) []*v2.Firewall {
+				return []*v2.Firewall{newFirewall("healthy", now.Add(-10*time.Minute), v2.ConditionTrue)}
+			},
+			wantDeleted: true,
+		},
+		{
+			name:          "create timeout of the set overrides global create timeout",
+			createTimeout: 5 * time.Minute,
+			set: &v2.FirewallSet{
+				Spec: v2.FirewallSetSpec{
+					CreateTimeout: &metav1.Duration{Duration: 30 * time.Minute},
+				},
+			},
+			firewall: func(now time.Time, name string) *v2.Firewall {
+				return newCreatingFirewall(name, now.Add(-10*time.Minute), v2.ConditionFalse)
+			},
+		},
+		{
+			name: "does nothing without any timeouts",
+			firewall: func(now time.Time, name string) *v2.Firewall {
+				return newFirewall(name, now.Add(-10*time.Minute), v2.ConditionFalse)
+			},
+		},
 		{
 			name:          "returns zero result for healthy firewall",
 			healthTimeout: 5 * time.Minute,
@@ -66,10 +139,29 @@ func TestTimeoutController_deleteIfUnhealthyOrTimeout(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fw := tt.firewall(now, fmt.Sprintf("fw-%s", t.Name()))
-			c := newTestController(t, tt.createTimeout, tt.healthTimeout, fw)
+			var (
+				fw   = tt.firewall(now, fmt.Sprintf("fw-%s", t.Name()))
+				fws  []*v2.Firewall
+				objs []client.Object
+			)
+
+			if tt.others != nil {
+				fws = tt.others(now)
+			}
+			fws = append(fws, fw)
 
-			res, err := c.deleteIfUnhealthyOrTimeout(context.Background(), fw)
+			for _, fw := range fws {
+				objs = append(objs, fw)
+			}
+
+			c := newTestController(t, tt.createTimeout, tt.healthTimeout, objs...)
+
+			set := tt.set
+			if set == nil {
+				set = &v2.FirewallSet{}
+			}
+
+			res, err := c.deleteIfUnhealthyOrTimeout(context.Background(), set, fws...)
 			if err != nil {
 				t.Fatalf("deleteIfUnhealthyOrTimeout() error = %v", err)
 			}
@@ -95,6 +187,135 @@ func TestTimeoutController_deleteIfUnhealthyOrTimeout(t *testing.T) {
 	}
 }
 
+func TestTimeoutController_recreationBudget(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name            string
+		recreations     []v2.FirewallRecreation
+		wantDeleted     bool
+		wantRecreations []string
+		wantCondition   v2.ConditionStatus
+		wantRequeue     bool
+	}{
+		{
+			name:            "recreates firewall within budget",
+			wantDeleted:     true,
+			wantRecreations: []string{"timed-out"},
+			wantCondition:   v2.ConditionFalse,
+		},
+		{
+			name: "does not recreate firewall when budget is exhausted",
+			recreations: []v2.FirewallRecreation{
+				{FirewallName: "a", Timestamp: metav1.NewTime(now.Add(-10 * time.Minute))},
+				{FirewallName: "b", Timestamp: metav1.NewTime(now.Add(-5 * time.Minute))},
+			},
+			wantRecreations: []string{"a", "b"},
+			wantCondition:   v2.ConditionTrue,
+			wantRequeue:     true,
+		},
+		{
+			name: "recreations outside of the window are not counted",
+			recreations: []v2.FirewallRecreation{
+				{FirewallName: "a", Timestamp: metav1.NewTime(now.Add(-2 * time.Hour))},
+				{FirewallName: "b", Timestamp: metav1.NewTime(now.Add(-5 * time.Minute))},
+			},
+			wantDeleted:     true,
+			wantRecreations: []string{"b", "timed-out"},
+			wantCondition:   v2.ConditionFalse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				fw      = newFirewall("timed-out", now.Add(-10*time.Minute), v2.ConditionFalse)
+				healthy = newFirewall("healthy", now.Add(-10*time.Minute), v2.ConditionTrue)
+				c       = newTestController(t, 0, 5*time.Minute, fw, healthy)
+				set     = &v2.FirewallSet{
+					Spec: v2.FirewallSetSpec{
+						RecreationBudget: &v2.FirewallRecreationBudget{
+							MaxRecreations: 2,
+							Window:         &metav1.Duration{Duration: time.Hour},
+						},
+					},
+					Status: v2.FirewallSetStatus{
+						Recreations: tt.recreations,
+					},
+				}
+			)
+
+			res, err := c.deleteIfUnhealthyOrTimeout(context.Background(), set, healthy, fw)
+			if err != nil {
+				t.Fatalf("deleteIfUnhealthyOrTimeout() error = %v", err)
+			}
+
+			err = c.client.Get(context.Background(), client.ObjectKeyFromObject(fw), &v2.Firewall{})
+			if tt.wantDeleted != apierrors.IsNotFound(err) {
+				t.Fatalf("expected firewall deleted to be %t, got err = %v", tt.wantDeleted, err)
+			}
+
+			var recreations []string
+			for _, r := range set.Status.Recreations {
+				recreations = append(recreations, r.FirewallName)
+			}
+			if diff := cmp.Diff(tt.wantRecreations, recreations); diff != "" {
+				t.Errorf("recreations diff (+got -want):\n %s", diff)
+			}
+
+			cond := set.Status.Conditions.Get(v2.FirewallSetReplicaFailure)
+			if cond == nil || cond.Status != tt.wantCondition {
+				t.Fatalf("expected replica failure condition to be %s, got %v", tt.wantCondition, cond)
+			}
+
+			if tt.wantRequeue {
+				// the oldest recreation leaves the window in 50 minutes
+				if res.RequeueAfter < 49*time.Minute || res.RequeueAfter > 51*time.Minute {
+					t.Fatalf("expected requeue after the oldest recreation left the window, got %s", res.RequeueAfter)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeoutController_quarantine(t *testing.T) {
+	now := time.Now()
+
+	var (
+		fw      = newFirewall("timed-out", now.Add(-10*time.Minute), v2.ConditionFalse)
+		healthy = newFirewall("healthy", now.Add(-10*time.Minute), v2.ConditionTrue)
+		c       = newTestController(t, 0, 5*time.Minute, fw, healthy)
+		set     = &v2.FirewallSet{
+			Spec: v2.FirewallSetSpec{
+				Quarantine: &v2.FirewallQuarantine{
+					TTL: &metav1.Duration{Duration: time.Hour},
+				},
+			},
+		}
+	)
+
+	_, err := c.deleteIfUnhealthyOrTimeout(context.Background(), set, healthy, fw)
+	if err != nil {
+		t.Fatalf("deleteIfUnhealthyOrTimeout() error = %v", err)
+	}
+
+	refetched := &v2.Firewall{}
+	err = c.client.Get(context.Background(), client.ObjectKeyFromObject(fw), refetched)
+	if err != nil {
+		t.Fatalf("expected quarantined firewall to still exist, got err = %v", err)
+	}
+
+	if !controllers.IsQuarantined(refetched) {
+		t.Fatalf("expected firewall to be quarantined")
+	}
+	if refetched.Distance != v2.FirewallLongestDistance {
+		t.Fatalf("expected quarantined firewall to have the longest distance, got %d", refetched.Distance)
+	}
+	if len(set.Status.Recreations) != 1 {
+		t.Fatalf("expected quarantine to be recorded as recreation, got %v", set.Status.Recreations)
+	}
+}
+
 func newFirewall(name string, seedConnectedTransition time.Time, seedConnectedStatus v2.ConditionStatus) *v2.Firewall {
 	baseTs := seedConnectedTransition
 	if baseTs.After(time.Now().Add(-10 * time.Minute)) {
@@ -167,7 +388,7 @@ func newTestController(t *testing.T, createTimeout, healthTimeout time.Duration,
 		c:        cfg,
 		client:   cl,
 		log:      logr.Discard(),
-		recorder: events.NewFakeRecorder(1),
+		recorder: events.NewFakeRecorder(10),
 	}
 }
 
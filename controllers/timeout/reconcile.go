@@ -2,12 +2,19 @@ package timeout
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"sort"
+	"time"
 
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/defaults"
 	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -41,35 +48,128 @@ func (c *controller) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, fmt.Errorf("unable to get owned firewalls: %w", err)
 	}
 
-	return c.deleteIfUnhealthyOrTimeout(ctx, ownedFirewalls...)
+	status := set.Status.DeepCopy()
+
+	res, err := c.deleteIfUnhealthyOrTimeout(ctx, set, ownedFirewalls...)
+
+	if !equality.Semantic.DeepEqual(status, &set.Status) {
+		updateErr := c.updateStatus(ctx, set)
+		if updateErr != nil {
+			return ctrl.Result{}, errors.Join(err, updateErr)
+		}
+	}
+
+	return res, err
+}
+
+func (c *controller) updateStatus(ctx context.Context, set *v2.FirewallSet) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		refetched := &v2.FirewallSet{}
+		err := c.client.Get(ctx, client.ObjectKeyFromObject(set), refetched)
+		if err != nil {
+			return fmt.Errorf("unable to re-fetch firewall set: %w", err)
+		}
+
+		refetched.Status.Recreations = set.Status.Recreations
+		refetched.Status.Conditions = set.Status.Conditions
+
+		err = c.client.Status().Update(ctx, refetched)
+		if err != nil {
+			return fmt.Errorf("unable to update firewall set status: %w", err)
+		}
+
+		return nil
+	})
 }
 
-func (c *controller) deleteIfUnhealthyOrTimeout(ctx context.Context, fws ...*v2.Firewall) (ctrl.Result, error) {
+func (c *controller) deleteIfUnhealthyOrTimeout(ctx context.Context, set *v2.FirewallSet, fws ...*v2.Firewall) (ctrl.Result, error) {
 	type fwWithStatus struct {
 		firewall *v2.Firewall
 		status   *v2.FirewallStatusEvalResult
 	}
 
-	var nextTimeouts []*fwWithStatus
+	var (
+		nextTimeouts  []*fwWithStatus
+		createTimeout = c.c.GetCreateTimeoutFor(set)
+		healthTimeout = c.c.GetFirewallHealthTimeoutFor(set)
+	)
+
+	if createTimeout <= 0 && healthTimeout <= 0 {
+		return ctrl.Result{}, nil
+	}
+
+	var (
+		now            = time.Now()
+		minAvailable   = minAvailableFor(set)
+		ready          = 0
+		budget         = set.Spec.RecreationBudget
+		window         = recreationWindowFor(set)
+		budgetExceeded = false
+	)
+
+	set.Status.Recreations = slices.DeleteFunc(set.Status.Recreations, func(r v2.FirewallRecreation) bool {
+		return r.Timestamp.Add(window).Before(now)
+	})
+
+	for _, fw := range fws {
+		if fw.DeletionTimestamp == nil && isReady(fw) {
+			ready++
+		}
+	}
 
 	for _, fw := range fws {
-		status := v2.EvaluateFirewallStatus(fw, c.c.GetCreateTimeout(), c.c.GetFirewallHealthTimeout())
+		status := v2.EvaluateFirewallStatus(fw, createTimeout, healthTimeout)
 
 		switch status.Result {
 		case v2.FirewallStatusCreateTimeout, v2.FirewallStatusHealthTimeout:
-			c.log.Info("firewall timeout exceeded, deleting from set", "reason", status.Reason, "firewall-name", fw.Name)
+			c.log.Info("firewall timeout exceeded, removing from set", "reason", status.Reason, "firewall-name", fw.Name)
 
 			if fw.DeletionTimestamp != nil {
 				c.log.Info("deletion timestamp on firewall already set", "firewall-name", fw.Name)
 				continue
 			}
 
-			err := c.c.GetSeedClient().Delete(ctx, fw)
-			if err != nil {
-				return ctrl.Result{}, err
+			// a timeout can also be caused by an outage of the seed api server, in which case all firewalls
+			// of the set time out at once. therefore, ready firewalls are only deleted within the disruption budget.
+			if isReady(fw) && ready <= minAvailable {
+				c.log.Info("not deleting firewall because it would violate the disruption budget", "firewall-name", fw.Name, "ready", ready, "min-available", minAvailable)
+				c.recorder.Eventf(fw, nil, corev1.EventTypeWarning, "DisruptionBudget", "skipping deletion", "not deleting firewall %s due to %s, %d of at least %d firewalls are ready", fw.Name, status, ready, minAvailable)
+				continue
+			}
+
+			if budget != nil && len(set.Status.Recreations) >= budget.MaxRecreations {
+				c.log.Info("not deleting firewall because the recreation budget is exhausted", "firewall-name", fw.Name, "recreations", len(set.Status.Recreations), "window", window.String())
+				budgetExceeded = true
+				continue
+			}
+
+			if isReady(fw) {
+				ready--
 			}
 
-			c.recorder.Eventf(fw, nil, corev1.EventTypeNormal, "Delete", "deleting firewall", "deleted firewall %s due to %s", fw.Name, status)
+			if set.Spec.Quarantine != nil {
+				ttl := quarantineTTLFor(set)
+
+				err := controllers.QuarantineFirewall(ctx, c.c.GetSeedClient(), fw, set, ttl)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+
+				c.recorder.Eventf(fw, nil, corev1.EventTypeWarning, "Quarantine", "quarantining firewall", "quarantined firewall %s for %s due to %s", fw.Name, ttl.String(), status)
+			} else {
+				err := c.c.GetSeedClient().Delete(ctx, fw)
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+
+				c.recorder.Eventf(fw, nil, corev1.EventTypeNormal, "Delete", "deleting firewall", "deleted firewall %s due to %s", fw.Name, status)
+			}
+
+			set.Status.Recreations = append(set.Status.Recreations, v2.FirewallRecreation{
+				FirewallName: fw.Name,
+				Reason:       status.Reason,
+				Timestamp:    metav1.NewTime(now),
+			})
 
 		case v2.FirewallStatusUnhealthy:
 			if status.TimeoutIn != nil {
@@ -81,6 +181,30 @@ func (c *controller) deleteIfUnhealthyOrTimeout(ctx context.Context, fws ...*v2.
 		}
 	}
 
+	if budgetExceeded {
+		cond := v2.NewCondition(v2.FirewallSetReplicaFailure, v2.ConditionTrue, "RecreationBudgetExceeded", fmt.Sprintf("Not recreating timed out firewalls because %d firewalls were already recreated within %s.", budget.MaxRecreations, window.String()))
+		set.Status.Conditions.Set(cond)
+
+		c.recorder.Eventf(set, nil, corev1.EventTypeWarning, "RecreationBudgetExceeded", "skipping recreation", "not recreating timed out firewalls, %d firewalls were already recreated within %s", budget.MaxRecreations, window.String())
+
+		// recreations are resumed as soon as the oldest recreation has left the window
+		oldest := now
+		for _, r := range set.Status.Recreations {
+			if r.Timestamp.Time.Before(oldest) {
+				oldest = r.Timestamp.Time
+			}
+		}
+
+		return ctrl.Result{
+			RequeueAfter: oldest.Add(window).Sub(now) + time.Second,
+		}, nil
+	}
+
+	if budget != nil || set.Status.Conditions.Get(v2.FirewallSetReplicaFailure) != nil {
+		cond := v2.NewCondition(v2.FirewallSetReplicaFailure, v2.ConditionFalse, "RecreationBudgetAvailable", "Timed out firewalls are recreated.")
+		set.Status.Conditions.Set(cond)
+	}
+
 	if len(nextTimeouts) > 0 {
 		sort.SliceStable(nextTimeouts, func(i, j int) bool {
 			return *nextTimeouts[i].status.TimeoutIn < *nextTimeouts[j].status.TimeoutIn
@@ -100,3 +224,33 @@ func (c *controller) deleteIfUnhealthyOrTimeout(ctx context.Context, fws ...*v2.
 
 	return ctrl.Result{}, nil
 }
+
+// minAvailableFor returns the amount of ready firewalls that must not be deleted because of a timeout,
+// which is at least one such that the last ready firewall of a set is never deleted.
+func minAvailableFor(set *v2.FirewallSet) int {
+	if set.Spec.MinAvailable != nil && *set.Spec.MinAvailable > 1 {
+		return *set.Spec.MinAvailable
+	}
+	return 1
+}
+
+// recreationWindowFor returns the time window in which the firewall recreations of the given set are counted.
+func recreationWindowFor(set *v2.FirewallSet) time.Duration {
+	if set.Spec.RecreationBudget == nil || set.Spec.RecreationBudget.Window == nil {
+		return defaults.DefaultRecreationWindow
+	}
+	return set.Spec.RecreationBudget.Window.Duration
+}
+
+// quarantineTTLFor returns the time a quarantined firewall of the given set is kept.
+func quarantineTTLFor(set *v2.FirewallSet) time.Duration {
+	if set.Spec.Quarantine == nil || set.Spec.Quarantine.TTL == nil {
+		return defaults.DefaultQuarantineTTL
+	}
+	return set.Spec.Quarantine.TTL.Duration
+}
+
+func isReady(fw *v2.Firewall) bool {
+	cond := fw.Status.Conditions.Get(v2.FirewallReady)
+	return cond != nil && cond.Status == v2.ConditionTrue
+}
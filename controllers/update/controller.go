@@ -20,29 +20,38 @@ import (
 )
 
 type controller struct {
-	c          *config.ControllerConfig
-	log        logr.Logger
-	recorder   events.EventRecorder
-	imageCache *cache.Cache[string, *models.V1ImageResponse]
+	c                 *config.ControllerConfig
+	log               logr.Logger
+	recorder          events.EventRecorder
+	imageCache        *cache.Cache[string, *models.V1ImageResponse]
+	imageListCache    *cache.Cache[string, []*models.V1ImageResponse]
+	releaseIndexCache *cache.Cache[string, *releaseIndex]
 }
 
-func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.Manager, c *config.ControllerConfig) error {
-	g := controllers.NewGenericController(log, c.GetSeedClient(), c.GetSeedNamespace(), &controller{
-		c:          c,
-		log:        log,
-		recorder:   recorder,
-		imageCache: newImageCache(c.GetMetal()),
+func SetupWithManager(log logr.Logger, recorder events.EventRecorder, mgr ctrl.Manager, configs *config.ControllerConfigs) error {
+	g := controllers.NewNamespacedGenericController(log, configs, func(c *config.ControllerConfig) controllers.Reconciler[*v2.FirewallDeployment] {
+		return &controller{
+			c:                 c,
+			log:               log,
+			recorder:          recorder,
+			imageCache:        newImageCache(c.GetMetal()),
+			imageListCache:    newImageListCache(c.GetMetal()),
+			releaseIndexCache: newReleaseIndexCache(c),
+		}
 	}).WithoutStatus()
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(
 			&v2.FirewallDeployment{},
 			builder.WithPredicates(
-				v2.AnnotationAddedPredicate(v2.MaintenanceAnnotation),
+				predicate.Or(
+					v2.AnnotationAddedPredicate(v2.MaintenanceAnnotation),
+					predicate.GenerationChangedPredicate{}, // maintenance windows may have changed
+				),
 			),
 		).
 		Named("Update").
-		WithEventFilter(predicate.NewPredicateFuncs(controllers.SkipOtherNamespace(c.GetSeedNamespace()))).
+		WithEventFilter(predicate.NewPredicateFuncs(controllers.SkipOtherNamespace(configs.GetSeedNamespaces()...))).
 		Complete(g)
 }
 
@@ -66,3 +75,14 @@ func newImageCache(m metalgo.Client) *cache.Cache[string, *models.V1ImageRespons
 		return resp.Payload, nil
 	})
 }
+
+func newImageListCache(m metalgo.Client) *cache.Cache[string, []*models.V1ImageResponse] {
+	return cache.New(5*time.Minute, func(ctx context.Context, _ string) ([]*models.V1ImageResponse, error) {
+		resp, err := m.Image().ListImages(image.NewListImagesParams().WithContext(ctx), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return resp.Payload, nil
+	})
+}
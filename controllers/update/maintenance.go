@@ -0,0 +1,89 @@
+package update
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+)
+
+// evaluateMaintenanceWindows returns whether the given time is within one of the maintenance windows and
+// the time when the next maintenance window begins.
+func evaluateMaintenanceWindows(windows []v2.FirewallMaintenanceWindow, now time.Time) (bool, time.Time, error) {
+	var (
+		within bool
+		next   time.Time
+	)
+
+	for _, w := range windows {
+		loc := time.UTC
+		if w.Timezone != "" {
+			var err error
+			loc, err = time.LoadLocation(w.Timezone)
+			if err != nil {
+				return false, time.Time{}, fmt.Errorf("invalid timezone %q: %w", w.Timezone, err)
+			}
+		}
+
+		begin, err := time.Parse("15:04", w.Begin)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid begin %q: %w", w.Begin, err)
+		}
+
+		end, err := time.Parse("15:04", w.End)
+		if err != nil {
+			return false, time.Time{}, fmt.Errorf("invalid end %q: %w", w.End, err)
+		}
+
+		weekdays := map[time.Weekday]bool{}
+		for _, day := range w.Weekdays {
+			weekday, ok := parseWeekday(day)
+			if !ok {
+				return false, time.Time{}, fmt.Errorf("invalid weekday %q", day)
+			}
+
+			weekdays[weekday] = true
+		}
+
+		local := now.In(loc)
+
+		// a window that began yesterday may span midnight, a week ahead always contains the next beginning
+		for offset := -1; offset <= 7; offset++ {
+			day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+
+			if len(weekdays) > 0 && !weekdays[day.Weekday()] {
+				continue
+			}
+
+			var (
+				windowBegin = time.Date(day.Year(), day.Month(), day.Day(), begin.Hour(), begin.Minute(), 0, 0, loc)
+				windowEnd   = time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+			)
+
+			if !windowEnd.After(windowBegin) {
+				windowEnd = windowEnd.AddDate(0, 0, 1)
+			}
+
+			if !now.Before(windowBegin) && now.Before(windowEnd) {
+				within = true
+			}
+
+			if windowBegin.After(now) && (next.IsZero() || windowBegin.Before(next)) {
+				next = windowBegin
+			}
+		}
+	}
+
+	return within, next, nil
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), s) {
+			return d, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,112 @@
+package update
+
+import (
+	"testing"
+	"time"
+
+	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_evaluateMaintenanceWindows(t *testing.T) {
+	// this is a monday
+	monday := func(hour int) time.Time {
+		return time.Date(2026, 10, 12, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		windows    []v2.FirewallMaintenanceWindow
+		now        time.Time
+		wantWithin bool
+		wantNext   time.Time
+		wantErr    bool
+	}{
+		{
+			name: "within daily window",
+			windows: []v2.FirewallMaintenanceWindow{
+				{Begin: "02:00", End: "04:00"},
+			},
+			now:        monday(3),
+			wantWithin: true,
+			wantNext:   time.Date(2026, 10, 13, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "outside of daily window",
+			windows: []v2.FirewallMaintenanceWindow{
+				{Begin: "02:00", End: "04:00"},
+			},
+			now:        monday(5),
+			wantWithin: false,
+			wantNext:   time.Date(2026, 10, 13, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "window end is exclusive",
+			windows: []v2.FirewallMaintenanceWindow{
+				{Begin: "02:00", End: "04:00"},
+			},
+			now:        monday(4),
+			wantWithin: false,
+			wantNext:   time.Date(2026, 10, 13, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "window spanning midnight that began the day before",
+			windows: []v2.FirewallMaintenanceWindow{
+				{Weekdays: []string{"Sunday"}, Begin: "22:00", End: "02:00"},
+			},
+			now:        monday(1),
+			wantWithin: true,
+			wantNext:   time.Date(2026, 10, 18, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "window on another weekday",
+			windows: []v2.FirewallMaintenanceWindow{
+				{Weekdays: []string{"wednesday"}, Begin: "02:00", End: "04:00"},
+			},
+			now:        monday(3),
+			wantWithin: false,
+			wantNext:   time.Date(2026, 10, 14, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "window in other timezone",
+			windows: []v2.FirewallMaintenanceWindow{
+				{Begin: "02:00", End: "04:00", Timezone: "Europe/Berlin"},
+			},
+			now:        monday(1),
+			wantWithin: true,
+			wantNext:   time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "earliest begin of multiple windows is next",
+			windows: []v2.FirewallMaintenanceWindow{
+				{Weekdays: []string{"Friday"}, Begin: "02:00", End: "04:00"},
+				{Weekdays: []string{"Tuesday"}, Begin: "12:00", End: "13:00"},
+			},
+			now:        monday(3),
+			wantWithin: false,
+			wantNext:   time.Date(2026, 10, 13, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "invalid timezone",
+			windows: []v2.FirewallMaintenanceWindow{
+				{Begin: "02:00", End: "04:00", Timezone: "Mars/Olympus_Mons"},
+			},
+			now:     monday(3),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			within, next, err := evaluateMaintenanceWindows(tt.windows, tt.now)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantWithin, within)
+			assert.True(t, tt.wantNext.Equal(next), "expected next window at %s, got %s", tt.wantNext, next)
+		})
+	}
+}
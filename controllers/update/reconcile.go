@@ -2,17 +2,52 @@ package update
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
 
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
 	"github.com/metal-stack/firewall-controller-manager/controllers"
+	"github.com/metal-stack/metal-go/api/models"
 	metalcommon "github.com/metal-stack/metal-lib/pkg/metal"
 	"k8s.io/client-go/util/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func (c *controller) Reconcile(r *controllers.Ctx[*v2.FirewallDeployment]) error {
-	return c.autoUpdateOS(r)
+	var next time.Time
+
+	if windows := r.Target.Spec.AutoUpdate.MaintenanceWindows; len(windows) > 0 {
+		within, nextBegin, err := evaluateMaintenanceWindows(windows, time.Now())
+		if err != nil {
+			return fmt.Errorf("unable to evaluate maintenance windows: %w", err)
+		}
+
+		if within {
+			r.Log.Info("within maintenance window")
+			r.WithinMaintenance = true
+		}
+
+		next = nextBegin
+	}
+
+	err := c.autoUpdateOS(r)
+	if err != nil {
+		return err
+	}
+
+	err = c.autoUpdateVersions(r)
+	if err != nil {
+		return err
+	}
+
+	if next.IsZero() {
+		return nil
+	}
+
+	return controllers.RequeueAfter(time.Until(next), "waiting for next maintenance window")
 }
 
 func (c *controller) autoUpdateOS(r *controllers.Ctx[*v2.FirewallDeployment]) error {
@@ -37,15 +72,31 @@ func (c *controller) autoUpdateOS(r *controllers.Ctx[*v2.FirewallDeployment]) er
 	var (
 		imageToResolve                = r.Target.Spec.Template.Spec.Image
 		isFullyQualifiedImageNotation = version.Patch() != 0
+		image                         *models.V1ImageResponse
 	)
 
 	if isFullyQualifiedImageNotation {
 		imageToResolve = fmt.Sprintf("%s-%d.%d", os, version.Major(), version.Minor())
 	}
 
-	image, err := c.imageCache.Get(r.Ctx, imageToResolve)
-	if err != nil {
-		return fmt.Errorf("unable to retrieve latest os image from metal-api: %w", err)
+	if constraint := r.Target.Spec.AutoUpdate.MachineImageConstraint; constraint != "" {
+		image, err = c.newestImageSatisfying(r, os, version, constraint)
+		if err != nil {
+			return err
+		}
+
+		if image == nil {
+			r.Log.Info("no os image satisfies the machine image constraint, not triggering auto-update", "constraint", constraint)
+			return nil
+		}
+
+		// the resolved image may be of another minor version than the one in the spec, so the spec needs to be updated in any case
+		isFullyQualifiedImageNotation = true
+	} else {
+		image, err = c.imageCache.Get(r.Ctx, imageToResolve)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve latest os image from metal-api: %w", err)
+		}
 	}
 
 	if image.ID == nil {
@@ -125,3 +176,141 @@ func (c *controller) autoUpdateOS(r *controllers.Ctx[*v2.FirewallDeployment]) er
 
 	return nil
 }
+
+// newestImageSatisfying returns the newest firewall image of the given os that satisfies the machine image constraint.
+// returns nil if no image satisfies the constraint.
+func (c *controller) newestImageSatisfying(r *controllers.Ctx[*v2.FirewallDeployment], os string, current *semver.Version, constraint string) (*models.V1ImageResponse, error) {
+	if constraint == v2.MachineImageConstraintPatchOnly {
+		constraint = fmt.Sprintf("~%d.%d", current.Major(), current.Minor())
+	}
+
+	constraints, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("machine image constraint cannot be parsed: %w", err)
+	}
+
+	images, err := c.imageListCache.Get(r.Ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve os images from metal-api: %w", err)
+	}
+
+	var (
+		newest        *models.V1ImageResponse
+		newestVersion *semver.Version
+		now           = time.Now()
+	)
+
+	for _, image := range images {
+		if image.ID == nil || !slices.Contains(image.Features, "firewall") {
+			continue
+		}
+
+		if image.ExpirationDate != nil && time.Time(*image.ExpirationDate).Before(now) {
+			continue
+		}
+
+		imageOS, v, err := metalcommon.GetOsAndSemverFromImage(*image.ID)
+		if err != nil || imageOS != os {
+			continue
+		}
+
+		if !constraints.Check(v) {
+			continue
+		}
+
+		if newestVersion == nil || v.GreaterThan(newestVersion) {
+			newest = image
+			newestVersion = v
+		}
+	}
+
+	return newest, nil
+}
+
+func (c *controller) autoUpdateVersions(r *controllers.Ctx[*v2.FirewallDeployment]) error {
+	autoUpdate := r.Target.Spec.AutoUpdate
+
+	if !autoUpdate.ControllerVersion && !autoUpdate.NftablesExporterVersion {
+		return nil
+	}
+
+	if !r.WithinMaintenance {
+		c.log.Info("not checking for newer firewall-controller and nftables-exporter versions, not in maintenance time window")
+		return nil
+	}
+
+	if c.c.GetReleaseIndexURL() == "" && c.c.GetReleaseIndexConfigMap() == "" {
+		c.log.Info("not checking for newer firewall-controller and nftables-exporter versions, no release index configured")
+		return nil
+	}
+
+	c.log.Info("checking for newer firewall-controller and nftables-exporter versions")
+
+	index, err := c.releaseIndexCache.Get(r.Ctx, "")
+	if err != nil {
+		return fmt.Errorf("unable to retrieve release index: %w", err)
+	}
+
+	var (
+		spec             = r.Target.Spec.Template.Spec
+		controllerUpdate *release
+		exporterUpdate   *release
+	)
+
+	// a version that cannot be resolved only prevents the auto-update of this component, the other component can still be updated
+	if autoUpdate.ControllerVersion {
+		controllerUpdate, err = newestRelease(index.FirewallController, spec.ControllerVersion)
+		if err != nil {
+			r.Log.Error(err, "unable to resolve newest firewall-controller release, skipping auto-update of firewall-controller")
+			controllerUpdate = nil
+		}
+	}
+
+	// the nftables-exporter is optional, so there is nothing to update if no version is specified
+	if autoUpdate.NftablesExporterVersion && spec.NftablesExporterVersion != "" {
+		exporterUpdate, err = newestRelease(index.NftablesExporter, spec.NftablesExporterVersion)
+		if err != nil {
+			r.Log.Error(err, "unable to resolve newest nftables-exporter release, skipping auto-update of nftables-exporter")
+			exporterUpdate = nil
+		}
+	}
+
+	if controllerUpdate == nil && exporterUpdate == nil {
+		r.Log.Info("no new firewall-controller or nftables-exporter version available, not triggering auto-update")
+		return nil
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		refetched := &v2.FirewallDeployment{}
+		err := c.c.GetSeedClient().Get(r.Ctx, client.ObjectKeyFromObject(r.Target), refetched)
+		if err != nil {
+			return fmt.Errorf("unable re-fetch firewall deployment: %w", err)
+		}
+
+		if controllerUpdate != nil {
+			r.Log.Info("newer firewall-controller version is available, triggering auto-update", "version", controllerUpdate.Version)
+
+			refetched.Spec.Template.Spec.ControllerVersion = controllerUpdate.Version
+			refetched.Spec.Template.Spec.ControllerURL = controllerUpdate.URL
+		}
+
+		if exporterUpdate != nil {
+			r.Log.Info("newer nftables-exporter version is available, triggering auto-update", "version", exporterUpdate.Version)
+
+			refetched.Spec.Template.Spec.NftablesExporterVersion = exporterUpdate.Version
+			refetched.Spec.Template.Spec.NftablesExporterURL = exporterUpdate.URL
+		}
+
+		err = c.c.GetSeedClient().Update(r.Ctx, refetched)
+		if err != nil {
+			return fmt.Errorf("unable to update firewall deployment: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/testr"
+	"github.com/go-openapi/strfmt"
 	"github.com/google/go-cmp/cmp"
 	v2 "github.com/metal-stack/firewall-controller-manager/api/v2"
 	"github.com/metal-stack/firewall-controller-manager/api/v2/config"
@@ -17,12 +20,22 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
+var constraintTestImages = []*models.V1ImageResponse{
+	{ID: new("firewall-ubuntu-3.0.20240503"), Features: []string{"firewall"}},
+	{ID: new("firewall-ubuntu-3.1.20240601"), Features: []string{"firewall"}},
+	{ID: new("firewall-ubuntu-3.2.20240701"), Features: []string{"firewall"}, ExpirationDate: new(strfmt.DateTime(time.Now().Add(-time.Hour)))},
+	{ID: new("firewall-ubuntu-3.3.20240801"), Features: []string{"machine"}},
+	{ID: new("firewall-ubuntu-4.0.20240901"), Features: []string{"firewall"}},
+	{ID: new("ubuntu-3.4.20241001"), Features: []string{"firewall"}},
+}
+
 func Test_controller_autoUpdateOS(t *testing.T) {
 	ctx := context.Background()
 	scheme := runtime.NewScheme()
@@ -202,6 +215,158 @@ func Test_controller_autoUpdateOS(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "auto-update to newest image satisfying constraint",
+			fwDeploy: &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deployment",
+					Namespace: "firewall",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Image: "firewall-ubuntu-3.0",
+						},
+					},
+					AutoUpdate: v2.FirewallAutoUpdate{
+						MachineImage:           true,
+						MachineImageConstraint: ">=3.0 <4.0",
+					},
+				},
+			},
+			withinMaintenance: true,
+			metalMocks: &metaltestclient.MetalMockFns{
+				Image: func(mock *mock.Mock) {
+					mock.On("ListImages", image.NewListImagesParams().WithContext(ctx), nil).Return(&image.ListImagesOK{
+						Payload: constraintTestImages,
+					}, nil)
+				},
+			},
+			existingFws: []v2.Firewall{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "a",
+						Namespace: "firewall",
+					},
+					Status: v2.FirewallStatus{
+						MachineStatus: &v2.MachineStatus{
+							ImageID: "firewall-ubuntu-3.0.20240101",
+						},
+					},
+				},
+			},
+			postTestFn: func(t *testing.T, c client.Client) {
+				fwdeploy := &v2.FirewallDeployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "deployment",
+						Namespace: "firewall",
+					},
+				}
+				err := c.Get(context.Background(), client.ObjectKeyFromObject(fwdeploy), fwdeploy)
+				require.NoError(t, err)
+
+				assert.Equal(t, "firewall-ubuntu-3.1.20240601", fwdeploy.Spec.Template.Spec.Image)
+				assert.Equal(t, fwdeploy.Annotations[v2.RollSetAnnotation], strconv.FormatBool(true))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "auto-update with patch-only constraint",
+			fwDeploy: &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deployment",
+					Namespace: "firewall",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Image: "firewall-ubuntu-3.0.20240101",
+						},
+					},
+					AutoUpdate: v2.FirewallAutoUpdate{
+						MachineImage:           true,
+						MachineImageConstraint: v2.MachineImageConstraintPatchOnly,
+					},
+				},
+			},
+			withinMaintenance: true,
+			metalMocks: &metaltestclient.MetalMockFns{
+				Image: func(mock *mock.Mock) {
+					mock.On("ListImages", image.NewListImagesParams().WithContext(ctx), nil).Return(&image.ListImagesOK{
+						Payload: constraintTestImages,
+					}, nil)
+				},
+			},
+			existingFws: []v2.Firewall{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "a",
+						Namespace: "firewall",
+					},
+					Status: v2.FirewallStatus{
+						MachineStatus: &v2.MachineStatus{
+							ImageID: "firewall-ubuntu-3.0.20240101",
+						},
+					},
+				},
+			},
+			postTestFn: func(t *testing.T, c client.Client) {
+				fwdeploy := &v2.FirewallDeployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "deployment",
+						Namespace: "firewall",
+					},
+				}
+				err := c.Get(context.Background(), client.ObjectKeyFromObject(fwdeploy), fwdeploy)
+				require.NoError(t, err)
+
+				assert.Equal(t, "firewall-ubuntu-3.0.20240503", fwdeploy.Spec.Template.Spec.Image)
+				assert.Equal(t, fwdeploy.Annotations[v2.RollSetAnnotation], strconv.FormatBool(true))
+			},
+			wantErr: nil,
+		},
+		{
+			name: "no image satisfies constraint",
+			fwDeploy: &v2.FirewallDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deployment",
+					Namespace: "firewall",
+				},
+				Spec: v2.FirewallDeploymentSpec{
+					Template: v2.FirewallTemplateSpec{
+						Spec: v2.FirewallSpec{
+							Image: "firewall-ubuntu-3.0.20240101",
+						},
+					},
+					AutoUpdate: v2.FirewallAutoUpdate{
+						MachineImage:           true,
+						MachineImageConstraint: "~5.0",
+					},
+				},
+			},
+			withinMaintenance: true,
+			metalMocks: &metaltestclient.MetalMockFns{
+				Image: func(mock *mock.Mock) {
+					mock.On("ListImages", image.NewListImagesParams().WithContext(ctx), nil).Return(&image.ListImagesOK{
+						Payload: constraintTestImages,
+					}, nil)
+				},
+			},
+			postTestFn: func(t *testing.T, c client.Client) {
+				fwdeploy := &v2.FirewallDeployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "deployment",
+						Namespace: "firewall",
+					},
+				}
+				err := c.Get(context.Background(), client.ObjectKeyFromObject(fwdeploy), fwdeploy)
+				require.NoError(t, err)
+
+				assert.Equal(t, "firewall-ubuntu-3.0.20240101", fwdeploy.Spec.Template.Spec.Image)
+				assert.NotContains(t, fwdeploy.Annotations, v2.RollSetAnnotation)
+			},
+			wantErr: nil,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -237,8 +402,9 @@ func Test_controller_autoUpdateOS(t *testing.T) {
 			require.NoError(t, err)
 
 			c := &controller{
-				c:          cc,
-				imageCache: newImageCache(mc),
+				c:              cc,
+				imageCache:     newImageCache(mc),
+				imageListCache: newImageListCache(mc),
 			}
 
 			r := &controllers.Ctx[*v2.FirewallDeployment]{
@@ -259,3 +425,189 @@ func Test_controller_autoUpdateOS(t *testing.T) {
 		})
 	}
 }
+
+func Test_controller_autoUpdateVersions(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, v2.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	index := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "releases",
+			Namespace: "firewall-controller-manager",
+		},
+		Data: map[string]string{
+			"releases.yaml": `
+firewall-controller:
+  - version: v2.3.4
+    url: https://firewall-controller/v2.3.4
+  - version: v2.4.0
+    url: https://firewall-controller/v2.4.0
+  - version: v2.5.0-rc.1
+    url: https://firewall-controller/v2.5.0-rc.1
+  - version: v3.0.0
+    url: https://firewall-controller/v3.0.0
+nftables-exporter:
+  - version: v0.3.0
+    url: https://nftables-exporter/v0.3.0
+  - version: v0.3.1
+    url: https://nftables-exporter/v0.3.1
+`,
+		},
+	}
+
+	newDeployment := func(autoUpdate v2.FirewallAutoUpdate, controllerVersion, exporterVersion string) *v2.FirewallDeployment {
+		return &v2.FirewallDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "deployment",
+				Namespace: "firewall",
+			},
+			Spec: v2.FirewallDeploymentSpec{
+				Template: v2.FirewallTemplateSpec{
+					Spec: v2.FirewallSpec{
+						ControllerVersion:       controllerVersion,
+						ControllerURL:           "https://firewall-controller/" + controllerVersion,
+						NftablesExporterVersion: exporterVersion,
+						NftablesExporterURL:     "https://nftables-exporter/" + exporterVersion,
+					},
+				},
+				AutoUpdate: autoUpdate,
+			},
+		}
+	}
+
+	tests := []struct {
+		name                  string
+		fwDeploy              *v2.FirewallDeployment
+		releaseIndexConfigMap string
+		withinMaintenance     bool
+		wantControllerVersion string
+		wantControllerURL     string
+		wantExporterVersion   string
+		wantExporterURL       string
+		wantErr               string
+	}{
+		{
+			name:                  "auto-update disabled",
+			fwDeploy:              newDeployment(v2.FirewallAutoUpdate{}, "v2.3.4", "v0.3.0"),
+			releaseIndexConfigMap: "releases",
+			withinMaintenance:     true,
+			wantControllerVersion: "v2.3.4",
+			wantControllerURL:     "https://firewall-controller/v2.3.4",
+			wantExporterVersion:   "v0.3.0",
+			wantExporterURL:       "https://nftables-exporter/v0.3.0",
+		},
+		{
+			name:                  "not in maintenance time window",
+			fwDeploy:              newDeployment(v2.FirewallAutoUpdate{ControllerVersion: true}, "v2.3.4", "v0.3.0"),
+			releaseIndexConfigMap: "releases",
+			withinMaintenance:     false,
+			wantControllerVersion: "v2.3.4",
+			wantControllerURL:     "https://firewall-controller/v2.3.4",
+			wantExporterVersion:   "v0.3.0",
+			wantExporterURL:       "https://nftables-exporter/v0.3.0",
+		},
+		{
+			name:                  "no release index configured",
+			fwDeploy:              newDeployment(v2.FirewallAutoUpdate{ControllerVersion: true}, "v2.3.4", "v0.3.0"),
+			withinMaintenance:     true,
+			wantControllerVersion: "v2.3.4",
+			wantControllerURL:     "https://firewall-controller/v2.3.4",
+			wantExporterVersion:   "v0.3.0",
+			wantExporterURL:       "https://nftables-exporter/v0.3.0",
+		},
+		{
+			name:                  "auto-update controller version within the same major version",
+			fwDeploy:              newDeployment(v2.FirewallAutoUpdate{ControllerVersion: true}, "v2.3.4", "v0.3.0"),
+			releaseIndexConfigMap: "releases",
+			withinMaintenance:     true,
+			wantControllerVersion: "v2.4.0",
+			wantControllerURL:     "https://firewall-controller/v2.4.0",
+			wantExporterVersion:   "v0.3.0",
+			wantExporterURL:       "https://nftables-exporter/v0.3.0",
+		},
+		{
+			name:                  "auto-update controller and nftables-exporter version",
+			fwDeploy:              newDeployment(v2.FirewallAutoUpdate{ControllerVersion: true, NftablesExporterVersion: true}, "v2.3.4", "v0.3.0"),
+			releaseIndexConfigMap: "releases",
+			withinMaintenance:     true,
+			wantControllerVersion: "v2.4.0",
+			wantControllerURL:     "https://firewall-controller/v2.4.0",
+			wantExporterVersion:   "v0.3.1",
+			wantExporterURL:       "https://nftables-exporter/v0.3.1",
+		},
+		{
+			name:                  "already running newest version",
+			fwDeploy:              newDeployment(v2.FirewallAutoUpdate{ControllerVersion: true}, "v3.0.0", "v0.3.0"),
+			releaseIndexConfigMap: "releases",
+			withinMaintenance:     true,
+			wantControllerVersion: "v3.0.0",
+			wantControllerURL:     "https://firewall-controller/v3.0.0",
+			wantExporterVersion:   "v0.3.0",
+			wantExporterURL:       "https://nftables-exporter/v0.3.0",
+		},
+		{
+			name:                  "current version cannot be parsed",
+			fwDeploy:              newDeployment(v2.FirewallAutoUpdate{ControllerVersion: true}, "latest", "v0.3.0"),
+			releaseIndexConfigMap: "releases",
+			withinMaintenance:     true,
+			wantControllerVersion: "latest",
+			wantControllerURL:     "https://firewall-controller/latest",
+			wantExporterVersion:   "v0.3.0",
+			wantExporterURL:       "https://nftables-exporter/v0.3.0",
+		},
+		{
+			name:                  "other component is updated when current version cannot be parsed",
+			fwDeploy:              newDeployment(v2.FirewallAutoUpdate{ControllerVersion: true, NftablesExporterVersion: true}, "latest", "v0.3.0"),
+			releaseIndexConfigMap: "releases",
+			withinMaintenance:     true,
+			wantControllerVersion: "latest",
+			wantControllerURL:     "https://firewall-controller/latest",
+			wantExporterVersion:   "v0.3.1",
+			wantExporterURL:       "https://nftables-exporter/v0.3.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.fwDeploy, index).Build()
+
+			cc, err := config.New(&config.NewControllerConfig{
+				SeedClient:                     c,
+				SeedNamespace:                  "firewall",
+				ReleaseIndexConfigMap:          tt.releaseIndexConfigMap,
+				SkipValidation:                 true,
+				ReleaseIndexConfigMapNamespace: "firewall-controller-manager",
+			})
+			require.NoError(t, err)
+
+			ctrl := &controller{
+				c:                 cc,
+				log:               testr.New(t),
+				releaseIndexCache: newReleaseIndexCache(cc),
+			}
+
+			r := &controllers.Ctx[*v2.FirewallDeployment]{
+				Ctx:               ctx,
+				Log:               testr.New(t),
+				Target:            tt.fwDeploy,
+				WithinMaintenance: tt.withinMaintenance,
+			}
+
+			err = ctrl.autoUpdateVersions(r)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			fwdeploy := &v2.FirewallDeployment{}
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(tt.fwDeploy), fwdeploy))
+
+			assert.Equal(t, tt.wantControllerVersion, fwdeploy.Spec.Template.Spec.ControllerVersion)
+			assert.Equal(t, tt.wantControllerURL, fwdeploy.Spec.Template.Spec.ControllerURL)
+			assert.Equal(t, tt.wantExporterVersion, fwdeploy.Spec.Template.Spec.NftablesExporterVersion)
+			assert.Equal(t, tt.wantExporterURL, fwdeploy.Spec.Template.Spec.NftablesExporterURL)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package update
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/metal-stack/firewall-controller-manager/api/v2/config"
+	"github.com/metal-stack/metal-lib/pkg/cache"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// releaseIndexConfigMapKey is the key in the data of the release index config map that contains the release index.
+const releaseIndexConfigMapKey = "releases.yaml"
+
+// releaseIndex contains the available releases of the components running on the firewall.
+//
+// example:
+//
+//	firewall-controller:
+//	  - version: v2.3.5
+//	    url: https://images.metal-stack.io/firewall-controller/v2.3.5/firewall-controller
+//	nftables-exporter:
+//	  - version: v0.3.1
+//	    url: https://images.metal-stack.io/nftables-exporter/v0.3.1/nftables-exporter
+type releaseIndex struct {
+	FirewallController []release `json:"firewall-controller"`
+	NftablesExporter   []release `json:"nftables-exporter"`
+}
+
+type release struct {
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+func newReleaseIndexCache(c *config.ControllerConfig) *cache.Cache[string, *releaseIndex] {
+	return cache.New(5*time.Minute, func(ctx context.Context, _ string) (*releaseIndex, error) {
+		var raw []byte
+
+		switch {
+		case c.GetReleaseIndexURL() != "":
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.GetReleaseIndexURL(), nil)
+			if err != nil {
+				return nil, err
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("release index endpoint responded with status code %d", resp.StatusCode)
+			}
+
+			raw, err = io.ReadAll(resp.Body)
+			if err != nil {
+				return nil, err
+			}
+		case c.GetReleaseIndexConfigMap() != "":
+			cm := &corev1.ConfigMap{}
+			err := c.GetSeedClient().Get(ctx, client.ObjectKey{Name: c.GetReleaseIndexConfigMap(), Namespace: c.GetReleaseIndexConfigMapNamespace()}, cm)
+			if err != nil {
+				return nil, err
+			}
+
+			data, ok := cm.Data[releaseIndexConfigMapKey]
+			if !ok {
+				return nil, fmt.Errorf("config map %q does not contain key %q", cm.Name, releaseIndexConfigMapKey)
+			}
+
+			raw = []byte(data)
+		default:
+			return nil, fmt.Errorf("no release index configured")
+		}
+
+		index := &releaseIndex{}
+		err := yaml.Unmarshal(raw, index)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse release index: %w", err)
+		}
+
+		return index, nil
+	})
+}
+
+// newestRelease returns the newest release that has the same major version as the current version
+// and is newer than the current version. returns nil if there is no such release.
+func newestRelease(releases []release, current string) (*release, error) {
+	currentVersion, err := semver.NewVersion(current)
+	if err != nil {
+		return nil, fmt.Errorf("version %q cannot be parsed: %w", current, err)
+	}
+
+	var (
+		newest        *release
+		newestVersion = currentVersion
+	)
+
+	for _, r := range releases {
+		v, err := semver.NewVersion(r.Version)
+		if err != nil {
+			continue
+		}
+
+		if v.Prerelease() != "" || v.Major() != currentVersion.Major() {
+			continue
+		}
+
+		if v.GreaterThan(newestVersion) {
+			newest = &r
+			newestVersion = v
+		}
+	}
+
+	return newest, nil
+}
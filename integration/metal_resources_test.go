@@ -3,12 +3,15 @@ package controllers_test
 import (
 	"time"
 
+	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/strfmt"
 	metalgo "github.com/metal-stack/metal-go"
+	"github.com/metal-stack/metal-go/api/client/partition"
 	"github.com/metal-stack/metal-go/api/models"
 	metalclient "github.com/metal-stack/metal-go/test/client"
 	"github.com/metal-stack/metal-lib/pkg/net"
 	"github.com/metal-stack/metal-lib/pkg/pointer"
+	"github.com/stretchr/testify/mock"
 )
 
 var (
@@ -315,6 +318,27 @@ var (
 // we are sharing a client for the tests, so we need to make sure we do not run contradicting tests in parallel
 // we can swap the client with this function
 func swapMetalClient(mockFns *metalclient.MetalMockFns) {
+	if mockFns.Partition == nil {
+		// rolling updates check the capacity before creating a new firewall set
+		mockFns.Partition = func(m *mock.Mock) {
+			m.On("PartitionCapacity", mock.Anything, nil).Return(func(params *partition.PartitionCapacityParams, _ runtime.ClientAuthInfoWriter, _ ...partition.ClientOption) (*partition.PartitionCapacityOK, error) {
+				return &partition.PartitionCapacityOK{
+					Payload: []*models.V1PartitionCapacity{
+						{
+							ID: &params.Body.ID,
+							Servers: []*models.V1ServerCapacity{
+								{
+									Size: &params.Body.Sizeid,
+									Free: 100,
+								},
+							},
+						},
+					},
+				}, nil
+			}).Maybe()
+		}
+	}
+
 	newClient, _ := metalclient.NewMetalMockClient(testingT, mockFns)
 
 	if metalClient == nil {
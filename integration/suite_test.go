@@ -138,11 +138,14 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).ToNot(HaveOccurred())
 
+	configs, err := controllerconfig.NewControllerConfigs(cc)
+	Expect(err).ToNot(HaveOccurred())
+
 	err = deployment.SetupWithManager(
 		ctrl.Log.WithName("controllers").WithName("deployment"),
 		mgr.GetEventRecorder("firewall-deployment-controller"),
 		mgr,
-		cc,
+		configs,
 	)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -150,7 +153,7 @@ var _ = BeforeSuite(func() {
 		ctrl.Log.WithName("controllers").WithName("set"),
 		mgr.GetEventRecorder("firewall-set-controller"),
 		mgr,
-		cc,
+		configs,
 	)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -158,7 +161,7 @@ var _ = BeforeSuite(func() {
 		ctrl.Log.WithName("controllers").WithName("firewall"),
 		mgr.GetEventRecorder("firewall-controller"),
 		mgr,
-		cc,
+		configs,
 	)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -166,7 +169,7 @@ var _ = BeforeSuite(func() {
 		ctrl.Log.WithName("controllers").WithName("update"),
 		mgr.GetEventRecorder("update-controller"),
 		mgr,
-		cc,
+		configs,
 	)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -174,15 +177,15 @@ var _ = BeforeSuite(func() {
 		ctrl.Log.WithName("controllers").WithName("timeout"),
 		mgr.GetEventRecorder("timeout-controller"),
 		mgr,
-		cc,
+		configs,
 	)
 	Expect(err).ToNot(HaveOccurred())
 
-	err = deployment.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), mgr, cc)
+	err = deployment.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), mgr, configs)
 	Expect(err).ToNot(HaveOccurred())
-	err = set.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), mgr, cc)
+	err = set.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), mgr, configs)
 	Expect(err).ToNot(HaveOccurred())
-	err = firewall.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), mgr, cc)
+	err = firewall.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), mgr, configs)
 	Expect(err).ToNot(HaveOccurred())
 
 	err = monitor.SetupWithManager(ctrl.Log.WithName("controllers").WithName("firewall-monitor"), mgr, cc)
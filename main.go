@@ -7,6 +7,8 @@ import (
 	"log"
 	"log/slog"
 	"os"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -16,11 +18,13 @@ import (
 	"github.com/metal-stack/metal-lib/pkg/tag"
 	"github.com/metal-stack/v"
 
+	corev1 "k8s.io/api/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/config"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
@@ -31,6 +35,7 @@ import (
 	"github.com/metal-stack/firewall-controller-manager/controllers/deployment"
 	"github.com/metal-stack/firewall-controller-manager/controllers/firewall"
 	"github.com/metal-stack/firewall-controller-manager/controllers/monitor"
+	"github.com/metal-stack/firewall-controller-manager/controllers/orphan"
 	"github.com/metal-stack/firewall-controller-manager/controllers/set"
 	"github.com/metal-stack/firewall-controller-manager/controllers/timeout"
 	"github.com/metal-stack/firewall-controller-manager/controllers/update"
@@ -61,11 +66,18 @@ func main() {
 		createTimeout           time.Duration
 		safetyBackoff           time.Duration
 		progressDeadline        time.Duration
+		orphanCheckInterval     time.Duration
+		orphanGracePeriod       time.Duration
 		clusterID               string
 		shootApiURL             string
 		internalShootApiURL     string
 		seedApiURL              string
 		certDir                 string
+		releaseIndexURL         string
+		releaseIndexConfigMap   string
+		namespaces              string
+		namespaceSelector       string
+		namespaceConfigMap      string
 	)
 
 	flag.StringVar(&logLevel, "log-level", "info", "the log level of the controller")
@@ -75,11 +87,16 @@ func main() {
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager")
 	flag.StringVar(&namespace, "namespace", "", "the namespace this controller is running")
+	flag.StringVar(&namespaces, "namespaces", "", "comma-separated list of namespaces this controller acts on, enables multi-namespace mode")
+	flag.StringVar(&namespaceSelector, "namespace-selector", "", "label selector of the namespaces this controller acts on, enables multi-namespace mode (namespaces are resolved on startup, a restart is required to pick up new namespaces)")
+	flag.StringVar(&namespaceConfigMap, "namespace-configmap", v2.FirewallControllerManager, "name of the config map that contains the namespace-specific configuration in multi-namespace mode")
 	flag.DurationVar(&reconcileInterval, "reconcile-interval", 10*time.Minute, "duration after which a resource is getting reconciled at minimum")
 	flag.DurationVar(&firewallHealthTimeout, "firewall-health-timeout", 0*time.Minute, "duration after a created firewall not getting ready is considered dead")
 	flag.DurationVar(&createTimeout, "create-timeout", 0*time.Minute, "duration after which a firewall in the creation phase will be recreated")
 	flag.DurationVar(&safetyBackoff, "safety-backoff", 10*time.Second, "duration after which a resource is getting reconciled at minimum")
 	flag.DurationVar(&progressDeadline, "progress-deadline", 15*time.Minute, "time after which a deployment is considered unhealthy instead of progressing (informational)")
+	flag.DurationVar(&orphanCheckInterval, "orphan-check-interval", 10*time.Minute, "interval in which the metal-api is checked for firewalls of the cluster without a firewall resource, zero disables the check")
+	flag.DurationVar(&orphanGracePeriod, "orphan-grace-period", 1*time.Hour, "duration after which a firewall without a firewall resource is freed, zero only reports orphaned firewalls")
 	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", -1, "grace period after which the controller shuts down")
 	flag.StringVar(&metalURL, "metal-api-url", "", "the url of the metal-stack api")
 	flag.StringVar(&clusterID, "cluster-id", "", "id of the cluster this controller is responsible for")
@@ -92,6 +109,8 @@ func main() {
 	flag.StringVar(&sshKeySecret, "ssh-key-secret-name", "", "the secret name of the ssh key for machine access")
 	flag.StringVar(&sshKeySecretNamespace, "ssh-key-secret-namespace", "", "the secret name of the ssh key for machine access")
 	flag.StringVar(&shootTokenPath, "shoot-token-path", "", "the path where to store the token file for shoot access")
+	flag.StringVar(&releaseIndexURL, "release-index-url", "", "url of a release index of the firewall-controller and nftables-exporter used for auto-updates")
+	flag.StringVar(&releaseIndexConfigMap, "release-index-configmap", "", "name of a config map in the namespace of the controller (--namespace) containing a release index of the firewall-controller and nftables-exporter used for auto-updates")
 
 	flag.Parse()
 
@@ -109,7 +128,8 @@ func main() {
 	ctrl.SetLogger(logr.FromSlogHandler(slogHandler))
 
 	var (
-		stop = ctrl.SetupSignalHandler()
+		stop       = ctrl.SetupSignalHandler()
+		restConfig = ctrl.GetConfigOrDie()
 	)
 
 	mclient, err := getMetalClient(metalURL)
@@ -117,7 +137,68 @@ func main() {
 		log.Fatalf("unable to create metal client %v", err)
 	}
 
-	seedMgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	// cannot use seedMgr.GetClient() because it gets initialized at a later point in time
+	// we have to create an own client
+	seedClient, err := client.New(restConfig, client.Options{
+		Scheme: scheme,
+	})
+	if err != nil {
+		log.Fatalf("unable to create seed client %v", err)
+	}
+
+	var (
+		multiNamespaceMode = namespaces != "" || namespaceSelector != ""
+		namespaceConfigs   = []*namespaceConfig{
+			{
+				namespace:             namespace,
+				shootKubeconfigSecret: shootKubeconfigSecret,
+				shootTokenSecret:      shootTokenSecret,
+				sshKeySecret:          sshKeySecret,
+				sshKeySecretNamespace: sshKeySecretNamespace,
+				clusterID:             clusterID,
+				shootApiURL:           shootApiURL,
+				internalShootApiURL:   internalShootApiURL,
+			},
+		}
+	)
+
+	if multiNamespaceMode {
+		var names []string
+		if namespaces != "" {
+			names = strings.Split(namespaces, ",")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		namespaceConfigs, err = readNamespaceConfigs(ctx, seedClient, names, namespaceSelector, namespaceConfigMap)
+		cancel()
+		if err != nil {
+			log.Fatalf("unable to read namespace configs %v", err)
+		}
+
+		l.Info("running in multi-namespace mode", "namespaces", len(namespaceConfigs))
+	}
+
+	var (
+		seedNamespaces  []string
+		cacheNamespaces = map[string]cache.Config{}
+		cacheByObject   = map[client.Object]cache.ByObject{}
+	)
+
+	for _, nc := range namespaceConfigs {
+		seedNamespaces = append(seedNamespaces, nc.namespace)
+		cacheNamespaces[nc.namespace] = cache.Config{}
+	}
+
+	if releaseIndexConfigMap != "" {
+		// in multi-namespace mode, the controller namespace is not necessarily one of the namespaces the controller acts on
+		configMapNamespaces := map[string]cache.Config{namespace: {}}
+		for ns := range cacheNamespaces {
+			configMapNamespaces[ns] = cache.Config{}
+		}
+		cacheByObject[&corev1.ConfigMap{}] = cache.ByObject{Namespaces: configMapNamespaces}
+	}
+
+	seedMgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme: scheme,
 		Metrics: server.Options{
 			BindAddress: metricsAddr,
@@ -127,10 +208,9 @@ func main() {
 			CertDir: certDir,
 		}),
 		Cache: cache.Options{
-			SyncPeriod: &reconcileInterval,
-			DefaultNamespaces: map[string]cache.Config{
-				namespace: {},
-			},
+			SyncPeriod:        &reconcileInterval,
+			DefaultNamespaces: cacheNamespaces,
+			ByObject:          cacheByObject,
 		},
 		HealthProbeBindAddress:  healthAddr,
 		LeaderElection:          enableLeaderElection,
@@ -142,167 +222,195 @@ func main() {
 		log.Fatalf("unable to setup firewall-controller-manager %v", err)
 	}
 
-	// cannot use seedMgr.GetClient() because it gets initialized at a later point in time
-	// we have to create an own client
-	seedClient, err := client.New(seedMgr.GetConfig(), client.Options{
-		Scheme: scheme,
-	})
-	if err != nil {
-		log.Fatalf("unable to create seed client %v", err)
-	}
-
-	if err := seedMgr.AddHealthzCheck("health", healthCheckFunc(l.WithGroup("health"), seedClient, namespace)); err != nil {
+	if err := seedMgr.AddHealthzCheck("health", healthCheckFunc(l.WithGroup("health"), seedClient, seedNamespaces)); err != nil {
 		log.Fatalf("unable to set up health check %v", err)
 	}
-	if err := seedMgr.AddReadyzCheck("check", healthCheckFunc(l.WithGroup("ready"), seedMgr.GetClient(), namespace)); err != nil {
+	if err := seedMgr.AddReadyzCheck("check", healthCheckFunc(l.WithGroup("ready"), seedMgr.GetClient(), seedNamespaces)); err != nil {
 		log.Fatalf("unable to set up ready check %v", err)
 	}
 
-	mustRegisterCustomMetrics(l.WithGroup("metrics"), seedClient, namespace)
+	mustRegisterCustomMetrics(l.WithGroup("metrics"), seedClient, seedNamespaces)
 
 	var (
-		externalShootAccess = &v2.ShootAccess{
-			GenericKubeconfigSecretName: shootKubeconfigSecret,
-			TokenSecretName:             shootTokenSecret,
-			Namespace:                   namespace,
-			APIServerURL:                shootApiURL,
-		}
-		internalShootAccess       = externalShootAccess.DeepCopy()
-		internalShootAccessHelper *helper.ShootAccessHelper
+		ccs       []*config.ControllerConfig
+		shootMgrs []ctrl.Manager
 	)
 
-	if internalShootApiURL != "" {
-		internalShootAccess.APIServerURL = internalShootApiURL
-	}
+	for _, nc := range namespaceConfigs {
+		l := l.With("namespace", nc.namespace)
+
+		var (
+			externalShootAccess = &v2.ShootAccess{
+				GenericKubeconfigSecretName: nc.shootKubeconfigSecret,
+				TokenSecretName:             nc.shootTokenSecret,
+				Namespace:                   nc.namespace,
+				APIServerURL:                nc.shootApiURL,
+			}
+			internalShootAccess       = externalShootAccess.DeepCopy()
+			internalShootAccessHelper *helper.ShootAccessHelper
+			nsShootApiURL             = nc.shootApiURL
+		)
+
+		if nc.internalShootApiURL != "" {
+			internalShootAccess.APIServerURL = nc.internalShootApiURL
+		}
 
-	if shootApiURL == "" {
-		shootApiURL = seedMgr.GetConfig().Host
+		if nsShootApiURL == "" {
+			nsShootApiURL = seedMgr.GetConfig().Host
 
-		internalShootAccessHelper = helper.NewSingleClusterModeHelper(seedMgr.GetConfig())
-		if err != nil {
-			log.Fatalf("unable to create shoot helper %v", err)
+			internalShootAccessHelper = helper.NewSingleClusterModeHelper(seedMgr.GetConfig())
+			l.Info("running in single-cluster mode")
+		} else {
+			internalShootAccessHelper = helper.NewShootAccessHelper(seedClient, internalShootAccess)
+			l.Info("running in split-cluster mode (seed and shoot client)")
 		}
-		l.Info("running in single-cluster mode")
-	} else {
-		internalShootAccessHelper = helper.NewShootAccessHelper(seedClient, internalShootAccess)
-		if err != nil {
-			log.Fatalf("unable to create shoot helper %v", err)
+
+		if shootTokenPath != "" {
+			// we do not mount the shoot client kubeconfig + token secret into the container
+			// through projected token mount as the other controllers deployed by Gardener.
+			//
+			// the reasoning for this is:
+			//
+			//   - we have to pass on the shoot access to the firewall-controller, too
+			//   - the firewall-controller is not a member of the Kubernetes cluster and
+			//     pushing files onto the firewall is not possible
+			//   - therefore, we defined flags for the shoot access generic kubeconfig and token
+			//     secret for this controller and expose the access secrets through the firewall
+			//     status resource, which can be read by the firewall-controller
+			//   - the firewall-controller can then create a client from these secrets but
+			//     it has to continuously update the token file because the token will expire
+			//   - we can re-use the same approach for this controller as well and do not have
+			//     to do any additional mounts for the deployment of the controller
+			//
+			tokenDir := shootTokenPath
+			if multiNamespaceMode {
+				// every shoot has its own token, so they have to be stored in separate directories
+				tokenDir = path.Join(shootTokenPath, nc.namespace)
+
+				err := os.MkdirAll(tokenDir, 0700)
+				if err != nil {
+					log.Fatalf("unable to create shoot token directory %v", err)
+				}
+			}
+
+			updater, err := helper.NewShootAccessTokenUpdater(internalShootAccessHelper, tokenDir)
+			if err != nil {
+				log.Fatalf("unable to create shoot access token updater %v", err)
+			}
+
+			err = updater.UpdateContinuously(ctrl.Log.WithName("token-updater").WithValues("namespace", nc.namespace), stop)
+			if err != nil {
+				log.Fatalf("unable to start token updater %v", err)
+			}
 		}
-		l.Info("running in split-cluster mode (seed and shoot client)")
-	}
 
-	if shootTokenPath != "" {
-		// we do not mount the shoot client kubeconfig + token secret into the container
-		// through projected token mount as the other controllers deployed by Gardener.
-		//
-		// the reasoning for this is:
-		//
-		//   - we have to pass on the shoot access to the firewall-controller, too
-		//   - the firewall-controller is not a member of the Kubernetes cluster and
-		//     pushing files onto the firewall is not possible
-		//   - therefore, we defined flags for the shoot access generic kubeconfig and token
-		//     secret for this controller and expose the access secrets through the firewall
-		//     status resource, which can be read by the firewall-controller
-		//   - the firewall-controller can then create a client from these secrets but
-		//     it has to continuously update the token file because the token will expire
-		//   - we can re-use the same approach for this controller as well and do not have
-		//     to do any additional mounts for the deployment of the controller
-		//
-		updater, err := helper.NewShootAccessTokenUpdater(internalShootAccessHelper, shootTokenPath)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		shootConfig, err := internalShootAccessHelper.RESTConfig(ctx)
+		cancel()
 		if err != nil {
-			log.Fatalf("unable to create shoot access token updater %v", err)
+			log.Fatalf("unable to create shoot config %v", err)
 		}
 
-		err = updater.UpdateContinuously(ctrl.Log.WithName("token-updater"), stop)
+		shootMgr, err := ctrl.NewManager(shootConfig, ctrl.Options{
+			Scheme: scheme,
+			Metrics: server.Options{
+				BindAddress: "0",
+			},
+			LeaderElection: false,
+			Cache: cache.Options{
+				DefaultNamespaces: map[string]cache.Config{
+					v2.FirewallShootNamespace: {},
+				},
+			},
+			Controller: ctrlconfig.Controller{
+				// every shoot manager runs its own firewall monitor controller
+				SkipNameValidation: pointer.Pointer(multiNamespaceMode),
+			},
+			GracefulShutdownTimeout: pointer.Pointer(time.Duration(0)),
+		})
 		if err != nil {
-			log.Fatalf("unable to start token updater %v", err)
+			log.Fatalf("unable to start firewall-controller-manager-monitor %v", err)
 		}
-	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+		cc, err := config.New(&config.NewControllerConfig{
+			SeedClient:                     seedMgr.GetClient(),
+			SeedConfig:                     seedMgr.GetConfig(),
+			SeedNamespace:                  nc.namespace,
+			SeedAPIServerURL:               seedApiURL,
+			ShootClient:                    shootMgr.GetClient(),
+			ShootConfig:                    shootMgr.GetConfig(),
+			ShootNamespace:                 v2.FirewallShootNamespace,
+			ShootAPIServerURL:              nsShootApiURL,
+			ShootAccess:                    externalShootAccess,
+			SSHKeySecretName:               nc.sshKeySecret,
+			SSHKeySecretNamespace:          nc.sshKeySecretNamespace,
+			ShootAccessHelper:              internalShootAccessHelper,
+			Metal:                          mclient,
+			ClusterTag:                     fmt.Sprintf("%s=%s", tag.ClusterID, nc.clusterID),
+			SafetyBackoff:                  safetyBackoff,
+			ProgressDeadline:               progressDeadline,
+			FirewallHealthTimeout:          firewallHealthTimeout,
+			CreateTimeout:                  createTimeout,
+			OrphanCheckInterval:            orphanCheckInterval,
+			OrphanGracePeriod:              orphanGracePeriod,
+			ReleaseIndexURL:                releaseIndexURL,
+			ReleaseIndexConfigMap:          releaseIndexConfigMap,
+			ReleaseIndexConfigMapNamespace: namespace,
+		})
+		if err != nil {
+			log.Fatalf("unable to create controller config for namespace %s: %v", nc.namespace, err)
+		}
 
-	shootConfig, err := internalShootAccessHelper.RESTConfig(ctx)
-	if err != nil {
-		log.Fatalf("unable to create shoot config %v", err)
-	}
+		if err := monitor.SetupWithManager(ctrl.Log.WithName("controllers").WithName("firewall-monitor").WithValues("namespace", nc.namespace), shootMgr, cc); err != nil {
+			log.Fatalf("unable to setup monitor controller: %v", err)
+		}
 
-	shootMgr, err := ctrl.NewManager(shootConfig, ctrl.Options{
-		Scheme: scheme,
-		Metrics: server.Options{
-			BindAddress: "0",
-		},
-		LeaderElection: false,
-		Cache: cache.Options{
-			DefaultNamespaces: map[string]cache.Config{
-				v2.FirewallShootNamespace: {},
-			},
-		},
-		GracefulShutdownTimeout: pointer.Pointer(time.Duration(0)),
-	})
-	if err != nil {
-		log.Fatalf("unable to start firewall-controller-manager-monitor %v", err)
+		ccs = append(ccs, cc)
+		shootMgrs = append(shootMgrs, shootMgr)
 	}
 
-	cc, err := config.New(&config.NewControllerConfig{
-		SeedClient:            seedMgr.GetClient(),
-		SeedConfig:            seedMgr.GetConfig(),
-		SeedNamespace:         namespace,
-		SeedAPIServerURL:      seedApiURL,
-		ShootClient:           shootMgr.GetClient(),
-		ShootConfig:           shootMgr.GetConfig(),
-		ShootNamespace:        v2.FirewallShootNamespace,
-		ShootAPIServerURL:     shootApiURL,
-		ShootAccess:           externalShootAccess,
-		SSHKeySecretName:      sshKeySecret,
-		SSHKeySecretNamespace: sshKeySecretNamespace,
-		ShootAccessHelper:     internalShootAccessHelper,
-		Metal:                 mclient,
-		ClusterTag:            fmt.Sprintf("%s=%s", tag.ClusterID, clusterID),
-		SafetyBackoff:         safetyBackoff,
-		ProgressDeadline:      progressDeadline,
-		FirewallHealthTimeout: firewallHealthTimeout,
-		CreateTimeout:         createTimeout,
-	})
+	configs, err := config.NewControllerConfigs(ccs...)
 	if err != nil {
-		log.Fatalf("unable to create controller config %v", err)
+		log.Fatalf("unable to create controller configs %v", err)
 	}
 
-	if err := deployment.SetupWithManager(ctrl.Log.WithName("controllers").WithName("deployment"), seedMgr.GetEventRecorder("firewall-deployment-controller"), seedMgr, cc); err != nil {
+	if err := deployment.SetupWithManager(ctrl.Log.WithName("controllers").WithName("deployment"), seedMgr.GetEventRecorder("firewall-deployment-controller"), seedMgr, configs); err != nil {
 		log.Fatalf("unable to setup deployment controller: %v", err)
 	}
-	if err := set.SetupWithManager(ctrl.Log.WithName("controllers").WithName("set"), seedMgr.GetEventRecorder("firewall-set-controller"), seedMgr, cc); err != nil {
+	if err := set.SetupWithManager(ctrl.Log.WithName("controllers").WithName("set"), seedMgr.GetEventRecorder("firewall-set-controller"), seedMgr, configs); err != nil {
 		log.Fatalf("unable to setup set controller: %v", err)
 	}
-	if err := firewall.SetupWithManager(ctrl.Log.WithName("controllers").WithName("firewall"), seedMgr.GetEventRecorder("firewall-controller"), seedMgr, cc); err != nil {
+	if err := firewall.SetupWithManager(ctrl.Log.WithName("controllers").WithName("firewall"), seedMgr.GetEventRecorder("firewall-controller"), seedMgr, configs); err != nil {
 		log.Fatalf("unable to setup firewall controller: %v", err)
 	}
-	if err := monitor.SetupWithManager(ctrl.Log.WithName("controllers").WithName("firewall-monitor"), shootMgr, cc); err != nil {
-		log.Fatalf("unable to setup monitor controller: %v", err)
-	}
-	if err := update.SetupWithManager(ctrl.Log.WithName("controllers").WithName("update"), seedMgr.GetEventRecorder("update-controller"), seedMgr, cc); err != nil {
+	if err := update.SetupWithManager(ctrl.Log.WithName("controllers").WithName("update"), seedMgr.GetEventRecorder("update-controller"), seedMgr, configs); err != nil {
 		log.Fatalf("unable to setup update controller: %v", err)
 	}
-	if err := timeout.SetupWithManager(ctrl.Log.WithName("controllers").WithName("timeout"), seedMgr.GetEventRecorder("timeout-controller"), seedMgr, cc); err != nil {
+	if err := timeout.SetupWithManager(ctrl.Log.WithName("controllers").WithName("timeout"), seedMgr.GetEventRecorder("timeout-controller"), seedMgr, configs); err != nil {
 		log.Fatalf("unable to setup timeout controller: %v", err)
 	}
+	if err := orphan.SetupWithManager(ctrl.Log.WithName("controllers").WithName("orphan"), seedMgr.GetEventRecorder("orphan-controller"), seedMgr, configs); err != nil {
+		log.Fatalf("unable to setup orphan controller: %v", err)
+	}
 
-	if err := deployment.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), seedMgr, cc); err != nil {
+	if err := deployment.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), seedMgr, configs); err != nil {
 		log.Fatalf("unable to setup webhook, controller deployment %v", err)
 	}
-	if err := set.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), seedMgr, cc); err != nil {
+	if err := set.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), seedMgr, configs); err != nil {
 		log.Fatalf("unable to setup webhook, controller set %v", err)
 	}
-	if err := firewall.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), seedMgr, cc); err != nil {
+	if err := firewall.SetupWebhookWithManager(ctrl.Log.WithName("defaulting-webhook"), seedMgr, configs); err != nil {
 		log.Fatalf("unable to setup webhook, controller firewall %v", err)
 	}
 
-	go func() {
-		l.Info("starting shoot controller", "version", v.V)
-		if err := shootMgr.Start(stop); err != nil {
-			log.Fatalf("problem running shoot controller %v", err)
-		}
-	}()
+	for _, shootMgr := range shootMgrs {
+		go func() {
+			l.Info("starting shoot controller", "version", v.V)
+			if err := shootMgr.Start(stop); err != nil {
+				log.Fatalf("problem running shoot controller %v", err)
+			}
+		}()
+	}
 
 	l.Info("starting seed controller", "version", v.V)
 	if err := seedMgr.Start(stop); err != nil {
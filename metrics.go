@@ -29,14 +29,14 @@ var (
 type collector struct {
 	log        *slog.Logger
 	seedClient client.Client
-	namespace  string
+	namespaces []string
 }
 
-func mustRegisterCustomMetrics(log *slog.Logger, seedClient client.Client, namespace string) {
+func mustRegisterCustomMetrics(log *slog.Logger, seedClient client.Client, namespaces []string) {
 	c := &collector{
 		log:        log,
 		seedClient: seedClient,
-		namespace:  namespace,
+		namespaces: namespaces,
 	}
 
 	metrics.Registry.MustRegister(c)
@@ -53,23 +53,25 @@ func (c *collector) Collect(ch chan<- prometheus.Metric) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	deploys := &v2.FirewallDeploymentList{}
-	err := c.seedClient.List(ctx, deploys, client.InNamespace(c.namespace))
-	if err != nil {
-		c.log.Error("unable to list firewall deployments", "error", err)
-		return
-	}
+	for _, namespace := range c.namespaces {
+		deploys := &v2.FirewallDeploymentList{}
+		err := c.seedClient.List(ctx, deploys, client.InNamespace(namespace))
+		if err != nil {
+			c.log.Error("unable to list firewall deployments", "namespace", namespace, "error", err)
+			continue
+		}
 
-	for _, deploy := range deploys.Items {
-		ch <- prometheus.MustNewConstMetric(firewallDeploymentReadyReplicasDesc, prometheus.GaugeValue,
-			float64(deploy.Status.ReadyReplicas),
-			deploy.Name,
-			deploy.Namespace,
-		)
-		ch <- prometheus.MustNewConstMetric(firewallDeploymentTargetReplicasDesc, prometheus.GaugeValue,
-			float64(deploy.Status.TargetReplicas),
-			deploy.Name,
-			deploy.Namespace,
-		)
+		for _, deploy := range deploys.Items {
+			ch <- prometheus.MustNewConstMetric(firewallDeploymentReadyReplicasDesc, prometheus.GaugeValue,
+				float64(deploy.Status.ReadyReplicas),
+				deploy.Name,
+				deploy.Namespace,
+			)
+			ch <- prometheus.MustNewConstMetric(firewallDeploymentTargetReplicasDesc, prometheus.GaugeValue,
+				float64(deploy.Status.TargetReplicas),
+				deploy.Name,
+				deploy.Namespace,
+			)
+		}
 	}
 }
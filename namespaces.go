@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// namespaceConfig contains the settings that are specific to a seed namespace, i.e. to a shoot cluster.
+type namespaceConfig struct {
+	namespace             string
+	shootKubeconfigSecret string
+	shootTokenSecret      string
+	sshKeySecret          string
+	sshKeySecretNamespace string
+	clusterID             string
+	shootApiURL           string
+	internalShootApiURL   string
+}
+
+// readNamespaceConfigs reads the namespace configs for multi-namespace mode. the namespaces are given explicitly
+// or through a label selector. every namespace is required to contain a config map with the namespace-specific
+// settings, the keys of the config map are named like the corresponding flags of the single-namespace mode.
+func readNamespaceConfigs(ctx context.Context, c client.Client, namespaces []string, selector, configMapName string) ([]*namespaceConfig, error) {
+	names := sets.New(namespaces...)
+
+	if selector != "" {
+		s, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse namespace selector: %w", err)
+		}
+
+		nsList := &corev1.NamespaceList{}
+		err = c.List(ctx, nsList, client.MatchingLabelsSelector{Selector: s})
+		if err != nil {
+			return nil, fmt.Errorf("unable to list namespaces: %w", err)
+		}
+
+		for _, ns := range nsList.Items {
+			names.Insert(ns.Name)
+		}
+	}
+
+	if names.Len() == 0 {
+		return nil, fmt.Errorf("no namespaces found to act on")
+	}
+
+	var result []*namespaceConfig
+
+	for _, ns := range sets.List(names) {
+		cm := &corev1.ConfigMap{}
+		err := c.Get(ctx, client.ObjectKey{Name: configMapName, Namespace: ns}, cm)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read config map of namespace %q: %w", ns, err)
+		}
+
+		nc := &namespaceConfig{
+			namespace:             ns,
+			shootKubeconfigSecret: cm.Data["shoot-kubeconfig-secret-name"],
+			shootTokenSecret:      cm.Data["shoot-token-secret-name"],
+			sshKeySecret:          cm.Data["ssh-key-secret-name"],
+			sshKeySecretNamespace: cm.Data["ssh-key-secret-namespace"],
+			clusterID:             cm.Data["cluster-id"],
+			shootApiURL:           cm.Data["shoot-api-url"],
+			internalShootApiURL:   cm.Data["internal-shoot-api-url"],
+		}
+
+		if nc.sshKeySecretNamespace == "" {
+			nc.sshKeySecretNamespace = ns
+		}
+
+		// the shoot namespace is the same for all shoots, so the shoots cannot be served by the seed cluster
+		if nc.shootApiURL == "" {
+			return nil, fmt.Errorf("config map of namespace %q does not contain a shoot api url, single-cluster mode is not supported in multi-namespace mode", ns)
+		}
+		if nc.clusterID == "" {
+			return nil, fmt.Errorf("config map of namespace %q does not contain a cluster id", ns)
+		}
+
+		result = append(result, nc)
+	}
+
+	return result, nil
+}